@@ -36,12 +36,13 @@ func (a jsonArray) hashCode(metadata []Metadata) [8]byte {
 }
 
 func (a jsonArray) Diff(n JsonNode, metadata ...Metadata) Diff {
+	metadata = withHashMemo(metadata)
 	n1 := dispatch(a, metadata)
 	n2 := dispatch(n, metadata)
-	return n1.diff(n2, make(path, 0), metadata)
+	return n1.diff(n2, make(Path, 0), metadata)
 }
 
-func (a jsonArray) diff(n JsonNode, path path, metadata []Metadata) Diff {
+func (a jsonArray) diff(n JsonNode, path Path, metadata []Metadata) Diff {
 	n1 := dispatch(a, metadata)
 	n2 := dispatch(n, metadata)
 	return n1.diff(n2, path, metadata)
@@ -51,7 +52,7 @@ func (a jsonArray) Patch(d Diff) (JsonNode, error) {
 	return patchAll(a, d)
 }
 
-func (a jsonArray) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (a jsonArray) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
 	_, metadata, _ := pathAhead.next()
 	n := dispatch(a, metadata)
 	return n.patch(pathBehind, pathAhead, oldValues, newValues)
@@ -0,0 +1,199 @@
+package jd
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// benchFixture is a pair of generated documents differing in about one
+// percent of their values.
+type benchFixture struct {
+	name     string
+	metadata []Metadata
+	a, b     string
+}
+
+func benchFixtures() []benchFixture {
+	return []benchFixture{{
+		name: "deep-object",
+		a:    genJson(deepObject(rand.New(rand.NewSource(1)), 6, 6, 0)),
+		b:    genJson(deepObject(rand.New(rand.NewSource(1)), 6, 6, 0.01)),
+	}, {
+		// Wide at every level, so PARALLEL work nests.
+		name: "deep-wide-object",
+		a:    genJson(deepObject(rand.New(rand.NewSource(6)), 3, 40, 0)),
+		b:    genJson(deepObject(rand.New(rand.NewSource(6)), 3, 40, 0.01)),
+	}, {
+		name: "large-list",
+		a:    genJson(largeList(rand.New(rand.NewSource(2)), 10000, 0)),
+		b:    genJson(largeList(rand.New(rand.NewSource(2)), 10000, 0.01)),
+	}, {
+		name:     "large-set-setkeys",
+		metadata: m(SET, Setkeys("id")),
+		a:        genJson(shuffled(rand.New(rand.NewSource(3)), largeList(rand.New(rand.NewSource(3)), 10000, 0))),
+		b:        genJson(shuffled(rand.New(rand.NewSource(4)), largeList(rand.New(rand.NewSource(3)), 10000, 0.01))),
+	}, {
+		name:     "multiset",
+		metadata: m(MULTISET),
+		a:        genJson(multisetValues(rand.New(rand.NewSource(5)), 10000, 0)),
+		b:        genJson(multisetValues(rand.New(rand.NewSource(5)), 10000, 0.01)),
+	}}
+}
+
+// deepObject generates nested objects depth levels deep with width
+// properties each. Leaves change with probability p.
+func deepObject(r *rand.Rand, depth, width int, p float64) interface{} {
+	if depth == 0 {
+		v := r.Intn(1000)
+		if r.Float64() < p {
+			v = -v - 1
+		}
+		return float64(v)
+	}
+	o := make(map[string]interface{}, width)
+	for i := 0; i < width; i++ {
+		o[fmt.Sprintf("key%v", i)] = deepObject(r, depth-1, width, p)
+	}
+	return o
+}
+
+// largeList generates n objects with a unique "id". Each object changes
+// with probability p.
+func largeList(r *rand.Rand, n int, p float64) []interface{} {
+	l := make([]interface{}, n)
+	for i := range l {
+		name := fmt.Sprintf("name-%v", r.Intn(1000000))
+		if r.Float64() < p {
+			name = "changed-" + name
+		}
+		l[i] = map[string]interface{}{
+			"id":   float64(i),
+			"name": name,
+			"tags": []interface{}{"a", "b", fmt.Sprintf("t%v", i%10)},
+		}
+	}
+	return l
+}
+
+// multisetValues generates n numbers with many repeats. Each value
+// changes with probability p.
+func multisetValues(r *rand.Rand, n int, p float64) []interface{} {
+	l := make([]interface{}, n)
+	for i := range l {
+		v := r.Intn(n / 10)
+		if r.Float64() < p {
+			v = -v - 1
+		}
+		l[i] = float64(v)
+	}
+	return l
+}
+
+func shuffled(r *rand.Rand, l []interface{}) []interface{} {
+	r.Shuffle(len(l), func(i, j int) {
+		l[i], l[j] = l[j], l[i]
+	})
+	return l
+}
+
+func genJson(v interface{}) string {
+	n, err := NewJsonNode(v)
+	if err != nil {
+		panic(err)
+	}
+	return n.Json()
+}
+
+func readBenchFixture(b *testing.B, f benchFixture) (JsonNode, JsonNode) {
+	a, err := ReadJsonString(f.a)
+	if err != nil {
+		b.Fatalf(err.Error())
+	}
+	n, err := ReadJsonString(f.b)
+	if err != nil {
+		b.Fatalf(err.Error())
+	}
+	return a, n
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, f := range benchFixtures() {
+		b.Run(f.name, func(b *testing.B) {
+			b.SetBytes(int64(len(f.a)))
+			for i := 0; i < b.N; i++ {
+				if _, err := ReadJsonString(f.a); err != nil {
+					b.Fatalf(err.Error())
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDiff(b *testing.B) {
+	for _, f := range benchFixtures() {
+		b.Run(f.name, func(b *testing.B) {
+			n1, n2 := readBenchFixture(b, f)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				n1.Diff(n2, f.metadata...)
+			}
+		})
+		b.Run(f.name+"-parallel", func(b *testing.B) {
+			n1, n2 := readBenchFixture(b, f)
+			metadata := append(f.metadata, PARALLEL)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				n1.Diff(n2, metadata...)
+			}
+		})
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	for _, f := range benchFixtures() {
+		n1, n2 := readBenchFixture(b, f)
+		d := n1.Diff(n2, f.metadata...)
+		b.Run(f.name+"-jd", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				d.Render()
+			}
+		})
+		b.Run(f.name+"-human", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				d.RenderHuman()
+			}
+		})
+		if _, err := d.RenderPatch(); err != nil {
+			// Set diffs have no JSON Patch.
+			continue
+		}
+		b.Run(f.name+"-patch", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				d.RenderPatch()
+			}
+		})
+	}
+}
+
+func BenchmarkPatch(b *testing.B) {
+	for _, f := range benchFixtures() {
+		b.Run(f.name, func(b *testing.B) {
+			n1, n2 := readBenchFixture(b, f)
+			d := n1.Diff(n2, f.metadata...)
+			if len(d) == 0 {
+				b.Fatalf("Want a non-empty diff.")
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Patching modifies objects in place.
+				b.StopTimer()
+				n, _ := ReadJsonString(f.a)
+				b.StartTimer()
+				if _, err := n.Patch(d); err != nil {
+					b.Fatalf(err.Error())
+				}
+			}
+		})
+	}
+}
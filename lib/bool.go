@@ -33,10 +33,10 @@ func (b jsonBool) hashCode(metadata []Metadata) [8]byte {
 }
 
 func (b jsonBool) Diff(n JsonNode, metadata ...Metadata) Diff {
-	return b.diff(n, make(path, 0), metadata)
+	return b.diff(n, make(Path, 0), metadata)
 }
 
-func (b jsonBool) diff(n JsonNode, path path, metadata []Metadata) Diff {
+func (b jsonBool) diff(n JsonNode, path Path, metadata []Metadata) Diff {
 	d := make(Diff, 0)
 	if b.Equals(n) {
 		return d
@@ -53,7 +53,7 @@ func (b jsonBool) Patch(d Diff) (JsonNode, error) {
 	return patchAll(b, d)
 }
 
-func (b jsonBool) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (b jsonBool) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
 	if len(pathAhead) != 0 {
 		return patchErrExpectColl(b, pathAhead[0])
 	}
@@ -124,6 +124,25 @@ func checkPatch(ctx *testContext, a, e string, diffLines ...string) {
 	}
 }
 
+func checkPatchJson(t *testing.T, a, want string, metadata []Metadata, diffLines ...string) {
+	initial, err := ReadJsonString(a)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	diff, err := ReadDiffString(s(diffLines...))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	b, err := initial.Patch(diff)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	got := b.Json(metadata...)
+	if got != want {
+		t.Errorf("%v.Patch(%v).Json() = %v. Want %v.", a, diffLines, got, want)
+	}
+}
+
 func checkPatchError(ctx *testContext, a string, diffLines ...string) {
 	diffString := ""
 	for _, dl := range diffLines {
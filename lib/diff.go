@@ -1,7 +1,7 @@
 package jd
 
 type DiffElement struct {
-	Path      []JsonNode
+	Path      Path
 	OldValues []JsonNode
 	NewValues []JsonNode
 }
@@ -0,0 +1,61 @@
+package jd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ApplyTo patches the Go value pointed to by ptr. The value is converted
+// with FromValue, patched and decoded back with encoding/json. Struct
+// fields which are not visible to encoding/json are preserved. The value
+// is left unchanged if the diff can't be applied.
+func (d Diff) ApplyTo(ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("ApplyTo requires a non-nil pointer. Got %T.", ptr)
+	}
+	n, err := fromValue(rv.Elem())
+	if err != nil {
+		return err
+	}
+	patched, err := n.Patch(d)
+	if err != nil {
+		return err
+	}
+	if isVoid(patched) {
+		return fmt.Errorf("Cannot apply a diff which removes the value.")
+	}
+	// Decode into a copy so a failure leaves the value unchanged.
+	result := reflect.New(rv.Elem().Type())
+	result.Elem().Set(rv.Elem())
+	clearJsonFields(result.Elem())
+	err = json.Unmarshal([]byte(patched.Json()), result.Interface())
+	if err != nil {
+		return err
+	}
+	rv.Elem().Set(result.Elem())
+	return nil
+}
+
+// clearJsonFields zeroes everything in v which encoding/json would decode
+// into, so values absent from the patched JSON don't survive. Fields of
+// structs which are not visible to encoding/json are kept.
+func clearJsonFields(v reflect.Value) {
+	if v.Kind() != reflect.Struct || v.Type().Implements(jsonUnmarshalerType) ||
+		reflect.PtrTo(v.Type()).Implements(jsonUnmarshalerType) {
+		v.Set(reflect.Zero(v.Type()))
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fv := v.Field(i)
+		if !fv.CanSet() || t.Field(i).Tag.Get("json") == "-" {
+			continue
+		}
+		// Struct values are cleared field by field. Everything else,
+		// including pointers which may be shared with the original
+		// value, is zeroed.
+		clearJsonFields(fv)
+	}
+}
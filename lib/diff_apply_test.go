@@ -0,0 +1,114 @@
+package jd
+
+import (
+	"testing"
+)
+
+type applyInner struct {
+	Name  string `json:"name"`
+	cache string
+}
+
+type applyStruct struct {
+	Replicas int               `json:"replicas"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Tags     []string          `json:"tags"`
+	Inner    applyInner        `json:"inner"`
+	Internal string            `json:"-"`
+	private  int
+}
+
+func TestApplyTo(t *testing.T) {
+	v := applyStruct{
+		Replicas: 1,
+		Labels:   map[string]string{"app": "a", "tier": "web"},
+		Tags:     []string{"x", "y"},
+		Inner:    applyInner{Name: "foo", cache: "c"},
+		Internal: "keep",
+		private:  7,
+	}
+	diff, err := ReadDiffString(s(
+		`@ ["inner","name"]`,
+		`- "foo"`,
+		`+ "bar"`,
+		`@ ["labels","tier"]`,
+		`- "web"`,
+		`@ ["replicas"]`,
+		`- 1`,
+		`+ 3`,
+		`@ ["tags",1]`,
+		`- "y"`,
+	))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	err = diff.ApplyTo(&v)
+	if err != nil {
+		t.Fatalf("Want no error. Got %v.", err)
+	}
+	if v.Replicas != 3 {
+		t.Errorf("Replicas = %v. Want 3.", v.Replicas)
+	}
+	if len(v.Labels) != 1 || v.Labels["app"] != "a" {
+		t.Errorf("Labels = %v. Want map[app:a].", v.Labels)
+	}
+	if len(v.Tags) != 1 || v.Tags[0] != "x" {
+		t.Errorf("Tags = %v. Want [x].", v.Tags)
+	}
+	if v.Inner.Name != "bar" || v.Inner.cache != "c" {
+		t.Errorf("Inner = %+v. Want {Name:bar cache:c}.", v.Inner)
+	}
+	if v.Internal != "keep" || v.private != 7 {
+		t.Errorf("Want fields hidden from JSON preserved. Got %+v.", v)
+	}
+}
+
+func TestApplyToRoundTrip(t *testing.T) {
+	a := applyStruct{Replicas: 1, Tags: []string{"x"}}
+	b := applyStruct{Replicas: 2, Tags: []string{"x", "y"}, Labels: map[string]string{"a": "b"}}
+	aNode, err := FromValue(a)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	bNode, err := FromValue(b)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	err = aNode.Diff(bNode).ApplyTo(&a)
+	if err != nil {
+		t.Fatalf("Want no error. Got %v.", err)
+	}
+	got, _ := FromValue(a)
+	if !got.Equals(bNode) {
+		t.Errorf("Got %v. Want %v.", got.Json(), bNode.Json())
+	}
+}
+
+func TestApplyToError(t *testing.T) {
+	v := applyStruct{Replicas: 1, Internal: "keep"}
+	diff, _ := ReadDiffString(s(
+		`@ ["replicas"]`,
+		`- 2`,
+		`+ 3`,
+	))
+	if err := diff.ApplyTo(&v); err == nil {
+		t.Errorf("Want error. Got nil.")
+	}
+	if v.Replicas != 1 || v.Internal != "keep" {
+		t.Errorf("Want value unchanged. Got %+v.", v)
+	}
+	if err := diff.ApplyTo(v); err == nil {
+		t.Errorf("Want error for non-pointer. Got nil.")
+	}
+	diff, _ = ReadDiffString(s(
+		`@ ["replicas"]`,
+		`- 1`,
+		`+ "three"`,
+	))
+	if err := diff.ApplyTo(&v); err == nil {
+		t.Errorf("Want error for mismatched type. Got nil.")
+	}
+	if v.Replicas != 1 {
+		t.Errorf("Want value unchanged. Got %+v.", v)
+	}
+}
@@ -0,0 +1,281 @@
+package jd
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Filter returns the elements of the diff for which keep returns true.
+func (d Diff) Filter(keep func(DiffElement) bool) Diff {
+	filtered := make(Diff, 0, len(d))
+	for _, e := range d {
+		if keep(e) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// Prefix re-roots the diff under path, so that a diff of a subtree
+// applies to the whole document.
+func (d Diff) Prefix(path Path) Diff {
+	prefixed := make(Diff, len(d))
+	for i, e := range d {
+		p := make(Path, 0, len(path)+len(e.Path))
+		p = append(p, path...)
+		e.Path = append(p, e.Path...)
+		prefixed[i] = e
+	}
+	return prefixed
+}
+
+// Strip returns the elements of the diff under prefix with prefix
+// removed, so that the diff applies to the subtree at prefix. A change
+// of the object property, list element or set element at prefix becomes
+// a change of the root. Elements outside of the subtree are dropped. It
+// is an error if an element at prefix cannot be expressed as a change of
+// the subtree, such as a list hunk inserting or removing more than one
+// element, an append or a change of the whole set.
+func (d Diff) Strip(prefix Path) (Diff, error) {
+	stripped := make(Diff, 0, len(d))
+	for _, e := range d {
+		if !e.Path.HasPrefix(prefix) {
+			continue
+		}
+		if len(e.Path) == len(prefix) && len(prefix) > 0 {
+			switch last := prefix[len(prefix)-1].(type) {
+			case jsonNumber:
+				if last == -1 {
+					return nil, fmt.Errorf(
+						"Cannot strip %v. Appends are relative to the list.",
+						prefix)
+				}
+				if len(e.OldValues) != 1 || len(e.NewValues) > 1 {
+					return nil, fmt.Errorf(
+						"Cannot strip %v. List hunk changes more than the element at the index.",
+						prefix)
+				}
+			case jsonObject:
+				if len(last.properties) == 0 {
+					return nil, fmt.Errorf(
+						"Cannot strip %v. Set changes are relative to the whole set.",
+						prefix)
+				}
+			}
+		}
+		e.Path = e.Path[len(prefix):].clone()
+		stripped = append(stripped, e)
+	}
+	return stripped, nil
+}
+
+// Compose returns a single diff equivalent to applying the given diffs
+// in order. Changes to the same scalar location are squashed together
+// when no other change in between depends on or moves that location.
+// Everything else is kept in order.
+func Compose(diffs ...Diff) Diff {
+	composed := make(Diff, 0)
+	for _, d := range diffs {
+		for _, e := range d {
+			composed = composeElement(composed, e)
+		}
+	}
+	return composed
+}
+
+func composeElement(d Diff, e DiffElement) Diff {
+	for i := len(d) - 1; i >= 0; i-- {
+		prev := d[i]
+		if !pathsEqual(prev.Path, e.Path) {
+			if pathsConflict(prev.Path, e.Path) {
+				break
+			}
+			continue
+		}
+		if !squashable(prev, e) {
+			break
+		}
+		squashed := DiffElement{
+			Path:      prev.Path,
+			OldValues: prev.OldValues,
+			NewValues: e.NewValues,
+		}
+		if nodesEqual(squashed.OldValues, squashed.NewValues) {
+			// The changes cancel out.
+			return append(d[:i:i], d[i+1:]...)
+		}
+		squashed.Path = squashed.Path.clone()
+		composed := append(d[:i:i], squashed)
+		return append(composed, d[i+1:]...)
+	}
+	e.Path = e.Path.clone()
+	return append(d, e)
+}
+
+// squashable reports whether e picks up exactly where prev left off at a
+// single value location.
+func squashable(prev, e DiffElement) bool {
+	if len(prev.OldValues) > 1 || len(prev.NewValues) > 1 ||
+		len(e.OldValues) > 1 || len(e.NewValues) > 1 {
+		return false
+	}
+	if len(e.Path) > 0 {
+		switch last := e.Path[len(e.Path)-1].(type) {
+		case jsonObject:
+			// Sets.
+			return false
+		case jsonNumber:
+			if int(last) < 0 {
+				// Appends.
+				return false
+			}
+		}
+	}
+	return nodesEqual(prev.NewValues, e.OldValues)
+}
+
+// pathsConflict reports whether changes at a and b can't be reordered:
+// one is inside the other or one moves the other within a collection.
+func pathsConflict(a, b Path) bool {
+	if a.HasPrefix(b) || b.HasPrefix(a) {
+		return true
+	}
+	return movesSiblings(a) && b.HasPrefix(a.Parent()) ||
+		movesSiblings(b) && a.HasPrefix(b.Parent())
+}
+
+func movesSiblings(p Path) bool {
+	if len(p) == 0 {
+		return false
+	}
+	switch p[len(p)-1].(type) {
+	case jsonNumber, jsonObject:
+		return true
+	}
+	return false
+}
+
+func pathsEqual(a, b Path) bool {
+	return len(a) == len(b) && a.HasPrefix(b)
+}
+
+func nodesEqual(a, b []JsonNode) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equals(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize returns an equivalent diff in canonical form. Consecutive
+// changes of the same location are merged, changes which cancel out are
+// dropped, set values are sorted and independent changes are ordered by
+// path.
+func (d Diff) Normalize() Diff {
+	normalized := make(Diff, 0, len(d))
+	for _, e := range d {
+		if isSetHunk(e.Path) {
+			normalized = mergeSetElement(normalized, e)
+		} else {
+			normalized = composeElement(normalized, e)
+		}
+	}
+	normalized = normalized.Filter(func(e DiffElement) bool {
+		return !nodesEqual(e.OldValues, e.NewValues)
+	})
+	// Bubble independent changes into path order. Changes which depend
+	// on each other keep their relative order.
+	for swapped := true; swapped; {
+		swapped = false
+		for i := 0; i+1 < len(normalized); i++ {
+			a, b := normalized[i], normalized[i+1]
+			if pathsEqual(a.Path, b.Path) || pathsConflict(a.Path, b.Path) {
+				continue
+			}
+			if a.Path.String() > b.Path.String() {
+				normalized[i], normalized[i+1] = b, a
+				swapped = true
+			}
+		}
+	}
+	return normalized
+}
+
+// Equals reports whether two diffs have the same normalized form.
+func (d Diff) Equals(other Diff) bool {
+	n1, n2 := d.Normalize(), other.Normalize()
+	if len(n1) != len(n2) {
+		return false
+	}
+	for i := range n1 {
+		if !pathsEqual(n1[i].Path, n2[i].Path) ||
+			!nodesEqual(n1[i].OldValues, n2[i].OldValues) ||
+			!nodesEqual(n1[i].NewValues, n2[i].NewValues) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSetHunk(p Path) bool {
+	if len(p) == 0 {
+		return false
+	}
+	o, ok := p[len(p)-1].(jsonObject)
+	return ok && len(o.properties) == 0
+}
+
+// mergeSetElement merges a set hunk into an earlier hunk of the same set.
+// Values both added and removed cancel out.
+func mergeSetElement(d Diff, e DiffElement) Diff {
+	i := len(d) - 1
+	for ; i >= 0; i-- {
+		if pathsEqual(d[i].Path, e.Path) {
+			break
+		}
+		if pathsConflict(d[i].Path, e.Path) {
+			i = -1
+			break
+		}
+	}
+	oldValues := nodeList()
+	newValues := nodeList()
+	if i >= 0 {
+		oldValues = append(oldValues, d[i].OldValues...)
+		newValues = append(newValues, d[i].NewValues...)
+	}
+	oldValues = append(oldValues, e.OldValues...)
+	newValues = append(newValues, e.NewValues...)
+	for j := 0; j < len(newValues); j++ {
+		for k, o := range oldValues {
+			if o.Equals(newValues[j]) {
+				oldValues = append(oldValues[:k:k], oldValues[k+1:]...)
+				newValues = append(newValues[:j:j], newValues[j+1:]...)
+				j--
+				break
+			}
+		}
+	}
+	sortByJson(oldValues)
+	sortByJson(newValues)
+	merged := DiffElement{
+		Path:      e.Path.clone(),
+		OldValues: oldValues,
+		NewValues: newValues,
+	}
+	if i < 0 {
+		return append(d, merged)
+	}
+	d[i] = merged
+	return d
+}
+
+func sortByJson(nodes []JsonNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Json() < nodes[j].Json()
+	})
+}
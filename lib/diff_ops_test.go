@@ -0,0 +1,372 @@
+package jd
+
+import (
+	"testing"
+)
+
+func checkDiffOp(t *testing.T, got Diff, want []string) {
+	t.Helper()
+	if got.Render() != s(want...) && !(len(want) == 0 && got.Render() == "") {
+		t.Errorf("Got:\n%vWant:\n%v", got.Render(), s(want...))
+	}
+}
+
+func readTestDiff(t *testing.T, diffLines ...string) Diff {
+	t.Helper()
+	d, err := ReadDiffString(s(diffLines...))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return d
+}
+
+func TestDiffFilter(t *testing.T) {
+	d := readTestDiff(t,
+		`@ ["a"]`,
+		`- 1`,
+		`@ ["b"]`,
+		`+ 2`,
+	)
+	got := d.Filter(func(e DiffElement) bool {
+		return len(e.NewValues) == 0
+	})
+	checkDiffOp(t, got, ss(
+		`@ ["a"]`,
+		`- 1`,
+	))
+}
+
+func stripTestDiff(t *testing.T, d Diff, prefix string) Diff {
+	t.Helper()
+	p, err := ParsePath(prefix)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	stripped, err := d.Strip(p)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	return stripped
+}
+
+func TestDiffPrefixAndStrip(t *testing.T) {
+	d := readTestDiff(t,
+		`@ ["a",0]`,
+		`- 1`,
+		`@ ["b"]`,
+		`+ 2`,
+	)
+	prefix, _ := ParsePath(`["x",["set"],{"id":1}]`)
+	prefixed := d.Prefix(prefix)
+	checkDiffOp(t, prefixed, ss(
+		`@ ["x",["set"],{"id":1},"a",0]`,
+		`- 1`,
+		`@ ["x",["set"],{"id":1},"b"]`,
+		`+ 2`,
+	))
+	checkDiffOp(t, stripTestDiff(t, prefixed, `["x",["set"],{"id":1}]`), ss(
+		`@ ["a",0]`,
+		`- 1`,
+		`@ ["b"]`,
+		`+ 2`,
+	))
+	checkDiffOp(t, stripTestDiff(t, d, `["a"]`), ss(
+		`@ [0]`,
+		`- 1`,
+	))
+	checkDiffOp(t, stripTestDiff(t, d, `["b"]`), ss(
+		`@ []`,
+		`+ 2`,
+	))
+	checkDiffOp(t, stripTestDiff(t, d, `["a",0]`), ss(
+		`@ []`,
+		`- 1`,
+	))
+	checkDiffOp(t, stripTestDiff(t, d, `[]`), ss(
+		`@ ["a",0]`,
+		`- 1`,
+		`@ ["b"]`,
+		`+ 2`,
+	))
+}
+
+func TestDiffStripReplacement(t *testing.T) {
+	a, _ := ReadJsonString(`{"a":[1]}`)
+	b, _ := ReadJsonString(`{"a":[2]}`)
+	stripped := stripTestDiff(t, a.Diff(b), `["a",0]`)
+	checkDiffOp(t, stripped, ss(
+		`@ []`,
+		`- 1`,
+		`+ 2`,
+	))
+	got, err := jsonNumber(1).Patch(stripped)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !got.Equals(jsonNumber(2)) {
+		t.Errorf("Wanted 2. Got %v", got.Json())
+	}
+	set := readTestDiff(t,
+		`@ ["s",["set","setkeys=id"],{"id":1}]`,
+		`- {"id":1,"v":1}`,
+		`+ {"id":1,"v":2}`,
+	)
+	checkDiffOp(t, stripTestDiff(t, set, `["s",["set","setkeys=id"],{"id":1}]`), ss(
+		`@ []`,
+		`- {"id":1,"v":1}`,
+		`+ {"id":1,"v":2}`,
+	))
+	multi := Diff{{
+		Path:      Path{jsonString("a"), jsonNumber(0)},
+		OldValues: []JsonNode{jsonNumber(1), jsonNumber(2)},
+		NewValues: []JsonNode{jsonNumber(3)},
+	}}
+	checkDiffOp(t, stripTestDiff(t, multi, `["a"]`), ss(
+		`@ [0]`,
+		`- 1`,
+		`- 2`,
+		`+ 3`,
+	))
+}
+
+func TestDiffStripError(t *testing.T) {
+	a0 := Path{jsonString("a"), jsonNumber(0)}
+	cases := []struct {
+		name   string
+		diff   Diff
+		prefix string
+	}{{
+		name: "more than one old value at index",
+		diff: Diff{{
+			Path:      a0,
+			OldValues: []JsonNode{jsonNumber(1), jsonNumber(2)},
+			NewValues: []JsonNode{jsonNumber(3)},
+		}},
+		prefix: `["a",0]`,
+	}, {
+		name: "more than one new value at index",
+		diff: Diff{{
+			Path:      a0,
+			OldValues: []JsonNode{jsonNumber(1)},
+			NewValues: []JsonNode{jsonNumber(2), jsonNumber(3)},
+		}},
+		prefix: `["a",0]`,
+	}, {
+		name:   "insertion at index",
+		diff:   readTestDiff(t, `@ ["a",0]`, `+ 0`),
+		prefix: `["a",0]`,
+	}, {
+		name:   "append",
+		diff:   readTestDiff(t, `@ ["a",-1]`, `+ 1`),
+		prefix: `["a",-1]`,
+	}, {
+		name:   "whole set",
+		diff:   readTestDiff(t, `@ ["s",["set"],{}]`, `+ 2`),
+		prefix: `["s",["set"],{}]`,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			prefix, err := ParsePath(c.prefix)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			stripped, err := c.diff.Strip(prefix)
+			if err == nil {
+				t.Errorf("Wanted error. Got:\n%v", stripped.Render())
+			}
+		})
+	}
+}
+
+func TestCompose(t *testing.T) {
+	cases := []struct {
+		name string
+		d1   []string
+		d2   []string
+		want []string
+	}{{
+		name: "squash object property",
+		d1:   ss(`@ ["a"]`, `- 1`, `+ 2`),
+		d2:   ss(`@ ["a"]`, `- 2`, `+ 3`),
+		want: ss(`@ ["a"]`, `- 1`, `+ 3`),
+	}, {
+		name: "cancel out",
+		d1:   ss(`@ ["a"]`, `+ 1`),
+		d2:   ss(`@ ["a"]`, `- 1`),
+		want: ss(),
+	}, {
+		name: "squash past unrelated change",
+		d1:   ss(`@ ["a"]`, `- 1`, `+ 2`, `@ ["b"]`, `+ 1`),
+		d2:   ss(`@ ["a"]`, `- 2`),
+		want: ss(`@ ["a"]`, `- 1`, `@ ["b"]`, `+ 1`),
+	}, {
+		name: "keep change inside changed value",
+		d1:   ss(`@ ["a"]`, `+ {}`),
+		d2:   ss(`@ ["a","b"]`, `+ 1`),
+		want: ss(`@ ["a"]`, `+ {}`, `@ ["a","b"]`, `+ 1`),
+	}, {
+		name: "keep list element moved by sibling",
+		d1:   ss(`@ ["a",1]`, `- 1`, `+ 2`, `@ ["a",0]`, `- 0`),
+		d2:   ss(`@ ["a",1]`, `- 2`, `+ 3`),
+		want: ss(`@ ["a",1]`, `- 1`, `+ 2`, `@ ["a",0]`, `- 0`, `@ ["a",1]`, `- 2`, `+ 3`),
+	}, {
+		name: "keep appends",
+		d1:   ss(`@ ["a",-1]`, `+ 1`),
+		d2:   ss(`@ ["a",-1]`, `+ 2`),
+		want: ss(`@ ["a",-1]`, `+ 1`, `@ ["a",-1]`, `+ 2`),
+	}, {
+		name: "keep mismatched values",
+		d1:   ss(`@ ["a"]`, `- 1`, `+ 2`),
+		d2:   ss(`@ ["a"]`, `- 3`, `+ 4`),
+		want: ss(`@ ["a"]`, `- 1`, `+ 2`, `@ ["a"]`, `- 3`, `+ 4`),
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d1 := readTestDiff(t, c.d1...)
+			d2 := readTestDiff(t, c.d2...)
+			checkDiffOp(t, Compose(d1, d2), c.want)
+		})
+	}
+}
+
+func TestComposeEquivalence(t *testing.T) {
+	cases := []struct {
+		metadata []Metadata
+		docs     []string
+	}{{
+		docs: ss(`{"a":1,"b":[1,2,3]}`, `{"a":2,"b":[2,3]}`, `{"a":3,"b":[2,3,4],"c":{}}`, `{"a":1}`),
+	}, {
+		docs: ss(`[1,2,3]`, `[1,5,3]`, `[1,6,3]`, `[1,6,3,7]`, `[1,7]`, `[6]`),
+	}, {
+		metadata: m(SET),
+		docs:     ss(`{"a":[1,2]}`, `{"a":[2,3]}`, `{"a":[3,4,{"b":1}]}`),
+	}, {
+		metadata: m(Setkeys("id")),
+		docs:     ss(`[{"id":1,"v":1}]`, `[{"id":1,"v":2}]`, `[{"id":1,"v":3},{"id":2}]`),
+	}}
+	for _, c := range cases {
+		nodes := make([]JsonNode, len(c.docs))
+		for i, doc := range c.docs {
+			n, err := ReadJsonString(doc)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			nodes[i] = n
+		}
+		diffs := make([]Diff, 0)
+		for i := 1; i < len(nodes); i++ {
+			diffs = append(diffs, nodes[i-1].Diff(nodes[i], c.metadata...))
+		}
+		composed := Compose(diffs...)
+		got, err := nodes[0].Patch(composed)
+		if err != nil {
+			t.Errorf("%v: patch error: %v\n%v", c.docs, err, composed.Render())
+			continue
+		}
+		want := nodes[len(nodes)-1]
+		if !got.Equals(want, c.metadata...) {
+			t.Errorf("%v: got %v. Want %v.\n%v", c.docs, got.Json(), want.Json(), composed.Render())
+		}
+	}
+}
+
+func TestDiffNormalize(t *testing.T) {
+	cases := []struct {
+		name string
+		diff []string
+		want []string
+	}{{
+		name: "empty",
+		diff: ss(),
+		want: ss(),
+	}, {
+		name: "merge split element",
+		diff: ss(`@ ["a"]`, `- 1`, `@ ["a"]`, `+ 2`),
+		want: ss(`@ ["a"]`, `- 1`, `+ 2`),
+	}, {
+		name: "drop no-op",
+		diff: ss(`@ ["a"]`, `- 1`, `+ 1`, `@ ["b"]`, `+ 2`),
+		want: ss(`@ ["b"]`, `+ 2`),
+	}, {
+		name: "order by path",
+		diff: ss(`@ ["b"]`, `+ 2`, `@ ["a"]`, `- 1`),
+		want: ss(`@ ["a"]`, `- 1`, `@ ["b"]`, `+ 2`),
+	}, {
+		name: "keep order of list changes",
+		diff: ss(`@ ["a",1]`, `- 2`, `@ ["a",0]`, `- 1`),
+		want: ss(`@ ["a",1]`, `- 2`, `@ ["a",0]`, `- 1`),
+	}, {
+		name: "merge and sort set hunks",
+		diff: ss(
+			`@ ["a",["set"],{}]`, `- 3`, `+ 2`,
+			`@ ["b"]`, `+ 1`,
+			`@ ["a",["set"],{}]`, `- 1`, `+ 3`, `+ 4`,
+		),
+		want: ss(
+			`@ ["a",["set"],{}]`, `- 1`, `+ 2`, `+ 4`,
+			`@ ["b"]`, `+ 1`,
+		),
+	}, {
+		name: "cancel set hunks",
+		diff: ss(`@ [["set"],{}]`, `+ 1`, `@ [["set"],{}]`, `- 1`),
+		want: ss(),
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d := readTestDiff(t, c.diff...)
+			checkDiffOp(t, d.Normalize(), c.want)
+		})
+	}
+}
+
+func TestDiffEquals(t *testing.T) {
+	cases := []struct {
+		name string
+		d1   []string
+		d2   []string
+		want bool
+	}{{
+		name: "same",
+		d1:   ss(`@ ["a"]`, `- 1`),
+		d2:   ss(`@ ["a"]`, `- 1`),
+		want: true,
+	}, {
+		name: "split",
+		d1:   ss(`@ ["a"]`, `- 1`, `+ 2`),
+		d2:   ss(`@ ["a"]`, `- 1`, `@ ["a"]`, `+ 2`),
+		want: true,
+	}, {
+		name: "set order",
+		d1:   ss(`@ ["a",{}]`, `+ 1`, `+ 2`, `@ ["b"]`, `- 1`),
+		d2:   ss(`@ ["b"]`, `- 1`, `@ ["a",{}]`, `+ 2`, `+ 1`),
+		want: true,
+	}, {
+		name: "different values",
+		d1:   ss(`@ ["a"]`, `- 1`, `+ 2`),
+		d2:   ss(`@ ["a"]`, `- 1`, `+ 3`),
+		want: false,
+	}, {
+		name: "different paths",
+		d1:   ss(`@ ["a"]`, `+ 2`),
+		d2:   ss(`@ ["b"]`, `+ 2`),
+		want: false,
+	}, {
+		name: "extra element",
+		d1:   ss(`@ ["a"]`, `+ 2`),
+		d2:   ss(`@ ["a"]`, `+ 2`, `@ ["b"]`, `+ 2`),
+		want: false,
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d1 := readTestDiff(t, c.d1...)
+			d2 := readTestDiff(t, c.d2...)
+			if got := d1.Equals(d2); got != c.want {
+				t.Errorf("Got %v. Want %v.", got, c.want)
+			}
+			if got := d2.Equals(d1); got != c.want {
+				t.Errorf("Reversed got %v. Want %v.", got, c.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"reflect"
 	"strings"
 )
 
@@ -74,7 +75,7 @@ func readDiff(s string) (Diff, error) {
 				return errorAt(i, "Invalid path. Want JSON list. Got %T.", p)
 			}
 			de = DiffElement{
-				Path:      path(pa).clone(),
+				Path:      Path(pa).clone(),
 				OldValues: []JsonNode{},
 				NewValues: []JsonNode{},
 			}
@@ -84,6 +85,9 @@ func readDiff(s string) (Diff, error) {
 			if err != nil {
 				return errorAt(i, "Invalid value. %v", err.Error())
 			}
+			if isVoid(v) {
+				return errorAt(i, "Invalid value. Expecting a JSON value.")
+			}
 			de.OldValues = append(de.OldValues, v)
 			state = OLD
 		case "+":
@@ -91,10 +95,13 @@ func readDiff(s string) (Diff, error) {
 			if err != nil {
 				return errorAt(i, "Invalid value. %v", err.Error())
 			}
+			if isVoid(v) {
+				return errorAt(i, "Invalid value. Expecting a JSON value.")
+			}
 			de.NewValues = append(de.NewValues, v)
 			state = NEW
 		default:
-			errorAt(i, "Unexpected %v.", dl[0])
+			return errorAt(i, "Unexpected %c.", dl[0])
 		}
 	}
 	if state == AT {
@@ -183,10 +190,20 @@ func readPatchDiffElement(patch []patchElement) (DiffElement, []patchElement, er
 		if patch[0].Path != p.Path {
 			return d, nil, fmt.Errorf("JSON Patch remove op must have the same path as test op.")
 		}
-		if patch[0].Value != p.Value {
+		if !reflect.DeepEqual(patch[0].Value, p.Value) {
 			return d, nil, fmt.Errorf("JSON Patch remove op must have the same value as test op.")
 		}
-		return d, patch[1:], nil
+		patch = patch[1:]
+		if len(patch) > 0 && patch[0].Op == "add" && patch[0].Path == p.Path {
+			// Replacement.
+			new, err := NewJsonNode(patch[0].Value)
+			if err != nil {
+				return d, nil, err
+			}
+			d.NewValues = []JsonNode{new}
+			patch = patch[1:]
+		}
+		return d, patch, nil
 	case "add":
 		d.Path, err = readPointer(p.Path)
 		if err != nil {
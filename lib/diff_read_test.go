@@ -34,6 +34,23 @@ func TestReadPatch(t *testing.T) {
 			`@ ["foo"]`,
 			`- 1`,
 		),
+	}, {
+		patch: s(
+			`[{"op":"test","path":"/foo/0","value":[1]},`,
+			`{"op":"remove","path":"/foo/0","value":[1]},`,
+			`{"op":"add","path":"/foo/0","value":2}]`,
+		),
+		diff: s(
+			`@ ["foo",0]`,
+			`- [1]`,
+			`+ 2`,
+		),
+	}, {
+		patch: s(
+			`[{"op":"test","path":"/foo","value":{"a":1}},`,
+			`{"op":"remove","path":"/foo","value":{"a":2}}]`,
+		),
+		wantErr: true,
 	}, {
 		patch: s(`[{"op":"add","path":"/foo/-","value":2}]`),
 		diff: s(
@@ -0,0 +1,134 @@
+package jd
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// emitFunc receives diff elements in order as they are found.
+type emitFunc func(DiffElement) error
+
+func collectDiff(fn func(emitFunc) error) Diff {
+	d := make(Diff, 0)
+	// Collecting never fails.
+	fn(func(e DiffElement) error {
+		d = append(d, e)
+		return nil
+	})
+	return d
+}
+
+// diffTo emits the diff of a and b. Objects and lists are streamed
+// property by property and element by element. Everything else is
+// diffed as a whole.
+func diffTo(a, b JsonNode, path Path, metadata []Metadata, emit emitFunc) error {
+	if _, ok := a.(jsonArray); ok {
+		a = dispatch(a, metadata)
+		b = dispatch(b, metadata)
+	}
+	switch a := a.(type) {
+	case jsonObject:
+		return a.diffTo(b, path, metadata, emit)
+	case jsonList:
+		return a.diffTo(b, path, metadata, emit)
+	}
+	return emitAll(a.diff(b, path, metadata), emit)
+}
+
+// DiffWriter encodes diff elements as they are written. Close must be
+// called after the last element.
+type DiffWriter interface {
+	WriteElement(DiffElement) error
+	Close() error
+}
+
+// DiffTo writes the diff of a and b to w as it is found, without
+// holding the whole diff in memory. The elements are the same and in the
+// same order as a.Diff(b, metadata...). DiffTo doesn't close w.
+func DiffTo(a, b JsonNode, w DiffWriter, metadata ...Metadata) error {
+	metadata = withHashMemo(metadata)
+	return diffTo(a, b, make(Path, 0), metadata, w.WriteElement)
+}
+
+type jdWriter struct {
+	w io.Writer
+}
+
+// NewJdWriter returns a DiffWriter of the native jd format, as rendered
+// by Diff.Render.
+func NewJdWriter(w io.Writer) DiffWriter {
+	return &jdWriter{w: w}
+}
+
+func (w *jdWriter) WriteElement(e DiffElement) error {
+	_, err := io.WriteString(w.w, e.Render())
+	return err
+}
+
+func (w *jdWriter) Close() error {
+	return nil
+}
+
+type humanWriter struct {
+	w io.Writer
+}
+
+// NewHumanWriter returns a DiffWriter of the human readable format, as
+// rendered by Diff.RenderHuman.
+func NewHumanWriter(w io.Writer) DiffWriter {
+	return &humanWriter{w: w}
+}
+
+func (w *humanWriter) WriteElement(e DiffElement) error {
+	_, err := io.WriteString(w.w, e.RenderHuman())
+	return err
+}
+
+func (w *humanWriter) Close() error {
+	return nil
+}
+
+type patchWriter struct {
+	w     io.Writer
+	count int
+}
+
+// NewPatchWriter returns a DiffWriter of JSON Patch (RFC 6902), as
+// rendered by Diff.RenderPatch. Close writes the end of the JSON array.
+func NewPatchWriter(w io.Writer) DiffWriter {
+	return &patchWriter{w: w}
+}
+
+func (w *patchWriter) WriteElement(e DiffElement) error {
+	ops, err := patchOps(e)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		sep := ","
+		if w.count == 0 {
+			sep = "["
+		}
+		b, err := json.Marshal(op)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w.w, sep); err != nil {
+			return err
+		}
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+		w.count++
+	}
+	return nil
+}
+
+func (w *patchWriter) Close() error {
+	end := "]"
+	if w.count == 0 {
+		end = "[]"
+	}
+	_, err := io.WriteString(w.w, end)
+	return err
+}
@@ -0,0 +1,95 @@
+package jd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestDiffTo(t *testing.T) {
+	cases := []struct {
+		metadata []Metadata
+		a        string
+		b        string
+	}{
+		{nil, `{"a":1}`, `{"a":1}`},
+		{nil, `{"a":1,"b":[1,2,{"c":"x"}],"d":true}`, `{"a":2,"b":[1,3,{"c":"y"},4],"e":null}`},
+		{nil, `[1,2,3]`, `[1]`},
+		{nil, `{"a":[1]}`, `{"a":{}}`},
+		{nil, `{"a":"the quick fox"}`, `{"a":"the slow fox"}`},
+		{nil, ``, `1`},
+		{m(SET), `{"a":[1,2,{"b":1}]}`, `{"a":[3,2,{"b":1}]}`},
+		{m(MULTISET), `[[1,1],2]`, `[[1],2]`},
+		{m(Setkeys("id")), `[{"id":1,"v":1}]`, `[{"id":1,"v":2}]`},
+	}
+	for _, c := range cases {
+		a, err := ReadJsonString(c.a)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		b, err := ReadJsonString(c.b)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		d := a.Diff(b, c.metadata...)
+		checkDiffTo(t, a, b, c.metadata, NewJdWriter, d.Render())
+		checkDiffTo(t, a, b, c.metadata, NewHumanWriter, d.RenderHuman())
+		if want, err := d.RenderPatch(); err == nil {
+			checkDiffTo(t, a, b, c.metadata, NewPatchWriter, want)
+		}
+	}
+}
+
+func checkDiffTo(t *testing.T, a, b JsonNode, metadata []Metadata, newWriter func(w io.Writer) DiffWriter, want string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	if err := DiffTo(a, b, w, metadata...); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("DiffTo(%v, %v) = %q. Want %q.", a.Json(), b.Json(), got, want)
+	}
+}
+
+type failingWriter struct {
+	count int
+}
+
+func (w *failingWriter) WriteElement(e DiffElement) error {
+	w.count++
+	return fmt.Errorf("stop")
+}
+
+func (w *failingWriter) Close() error {
+	return nil
+}
+
+func TestDiffToStops(t *testing.T) {
+	a, _ := ReadJsonString(`{"a":[1,2,3],"b":{"c":1}}`)
+	b, _ := ReadJsonString(`{"a":[4,5,6],"b":{"c":2}}`)
+	w := &failingWriter{}
+	if err := DiffTo(a, b, w); err == nil || err.Error() != "stop" {
+		t.Errorf("Want error stop. Got %v.", err)
+	}
+	if w.count != 1 {
+		t.Errorf("Want 1 element written. Got %v.", w.count)
+	}
+}
+
+func TestPatchWriterError(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewPatchWriter(&buf)
+	p, _ := ParsePath(`[{}]`)
+	err := w.WriteElement(DiffElement{
+		Path:      p,
+		NewValues: nodeList(jsonNumber(1)),
+	})
+	if err == nil {
+		t.Errorf("Want error for set path.")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 func (d DiffElement) Render() string {
@@ -46,42 +47,154 @@ func (d Diff) Render() string {
 func (d Diff) RenderPatch() (string, error) {
 	patch := []patchElement{}
 	for _, element := range d {
-		path, err := writePointer(element.Path)
+		ops, err := patchOps(element)
 		if err != nil {
 			return "", err
 		}
-		if len(element.OldValues) > 1 {
-			return "", fmt.Errorf("Cannot render more than one old value in a JSON Patch op.")
+		patch = append(patch, ops...)
+	}
+	patchJson, err := json.Marshal(patch)
+	if err != nil {
+		return "", err
+	}
+	return string(patchJson), nil
+}
+
+func patchOps(element DiffElement) ([]patchElement, error) {
+	path, err := writePointer(element.Path)
+	if err != nil {
+		return nil, err
+	}
+	if len(element.OldValues) > 1 {
+		return nil, fmt.Errorf("Cannot render more than one old value in a JSON Patch op.")
+	}
+	if len(element.NewValues) > 1 {
+		return nil, fmt.Errorf("Cannot render more than one new value in a JSON Patch op.")
+	}
+	if len(element.OldValues) == 0 && len(element.NewValues) == 0 {
+		return nil, fmt.Errorf("Cannot render empty diff element as JSON Patch op.")
+	}
+	patch := []patchElement{}
+	if len(element.OldValues) == 1 {
+		patch = append(patch, patchElement{
+			Op:    "test",
+			Path:  path,
+			Value: element.OldValues[0],
+		})
+		patch = append(patch, patchElement{
+			Op:    "remove",
+			Path:  path,
+			Value: element.OldValues[0],
+		})
+	}
+	if len(element.NewValues) == 1 {
+		patch = append(patch, patchElement{
+			Op:    "add",
+			Path:  path,
+			Value: element.NewValues[0],
+		})
+	}
+	return patch, nil
+}
+
+// humanContextLines is the number of unchanged lines shown around changed
+// lines of a multi-line string.
+const humanContextLines = 3
+
+// RenderHuman renders the diff element like Render except that a string
+// replaced by another string is shown as a sub-diff: line by line for
+// multi-line strings and word by word (or character by character)
+// otherwise. The output is meant for reading and cannot be read back as a
+// diff.
+func (d DiffElement) RenderHuman() string {
+	if len(d.OldValues) != 1 || len(d.NewValues) != 1 {
+		return d.Render()
+	}
+	oldValue, isString1 := d.OldValues[0].(jsonString)
+	newValue, isString2 := d.NewValues[0].(jsonString)
+	if !isString1 || !isString2 {
+		return d.Render()
+	}
+	b := bytes.NewBuffer(nil)
+	b.WriteString("@ ")
+	b.Write([]byte(jsonArray(d.Path).Json()))
+	b.WriteString("\n")
+	if isMultiline(string(oldValue)) || isMultiline(string(newValue)) {
+		edits := diffTokens(
+			strings.Split(string(oldValue), "\n"),
+			strings.Split(string(newValue), "\n"))
+		renderLineEdits(b, edits)
+	} else {
+		edits := diffTokens(
+			splitWords(string(oldValue)),
+			splitWords(string(newValue)))
+		b.WriteString("~ ")
+		renderWordEdits(b, edits)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func renderLineEdits(b *bytes.Buffer, edits []stringEdit) {
+	keep := make([]bool, len(edits))
+	for i, e := range edits {
+		if e.op == ' ' {
+			continue
 		}
-		if len(element.NewValues) > 1 {
-			return "", fmt.Errorf("Cannot render more than one new value in a JSON Patch op.")
+		for j := i - humanContextLines; j <= i+humanContextLines; j++ {
+			if j >= 0 && j < len(edits) {
+				keep[j] = true
+			}
 		}
-		if len(element.OldValues) == 0 && len(element.NewValues) == 0 {
-			return "", fmt.Errorf("Cannot render empty diff element as JSON Patch op.")
+	}
+	skipping := false
+	for i, e := range edits {
+		if !keep[i] {
+			if !skipping {
+				b.WriteString("  ...\n")
+				skipping = true
+			}
+			continue
 		}
-		if len(element.OldValues) == 1 {
-			patch = append(patch, patchElement{
-				Op:    "test",
-				Path:  path,
-				Value: element.OldValues[0],
-			})
-			patch = append(patch, patchElement{
-				Op:    "remove",
-				Path:  path,
-				Value: element.OldValues[0],
-			})
+		skipping = false
+		b.WriteByte(e.op)
+		b.WriteByte(' ')
+		b.WriteString(e.token)
+		b.WriteString("\n")
+	}
+}
+
+func renderWordEdits(b *bytes.Buffer, edits []stringEdit) {
+	var op byte = ' '
+	closeOp := func() {
+		switch op {
+		case '-':
+			b.WriteString("-]")
+		case '+':
+			b.WriteString("+}")
 		}
-		if len(element.NewValues) == 1 {
-			patch = append(patch, patchElement{
-				Op:    "add",
-				Path:  path,
-				Value: element.NewValues[0],
-			})
+	}
+	for _, e := range edits {
+		if e.op != op {
+			closeOp()
+			switch e.op {
+			case '-':
+				b.WriteString("[-")
+			case '+':
+				b.WriteString("{+")
+			}
+			op = e.op
 		}
+		b.WriteString(e.token)
 	}
-	patchJson, err := json.Marshal(patch)
-	if err != nil {
-		return "", err
+	closeOp()
+}
+
+// RenderHuman renders the diff for reading. See DiffElement.RenderHuman.
+func (d Diff) RenderHuman() string {
+	b := bytes.NewBuffer(nil)
+	for _, element := range d {
+		b.WriteString(element.RenderHuman())
 	}
-	return string(patchJson), nil
+	return b.String()
 }
@@ -1,6 +1,7 @@
 package jd
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -92,3 +93,81 @@ func TestDiffRenderPatch(t *testing.T) {
 		}
 	}
 }
+
+func TestDiffRenderHuman(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    string
+		b    string
+		want []string
+	}{{
+		name: "non-string values render as jd",
+		a:    `{"a":1}`,
+		b:    `{"a":2}`,
+		want: ss(
+			`@ ["a"]`,
+			`- 1`,
+			`+ 2`,
+		),
+	}, {
+		name: "words",
+		a:    `{"a":"the quick brown fox"}`,
+		b:    `{"a":"the quick red fox"}`,
+		want: ss(
+			`@ ["a"]`,
+			`~ the quick [-brown-]{+red+} fox`,
+		),
+	}, {
+		name: "characters",
+		a:    `{"cpu":"100m"}`,
+		b:    `{"cpu":"200m"}`,
+		want: ss(
+			`@ ["cpu"]`,
+			`~ [-1-]{+2+}00m`,
+		),
+	}, {
+		name: "lines",
+		a:    `"a\nb\nc\n"`,
+		b:    `"a\nB\nc\nd\n"`,
+		want: ss(
+			`@ []`,
+			`  a`,
+			`- b`,
+			`+ B`,
+			`  c`,
+			`+ d`,
+			`  `,
+		),
+	}, {
+		name: "lines with context",
+		a:    `"1\n2\n3\n4\n5\n6\n7\n8\n9\n10"`,
+		b:    `"1\n2\n3\n4\n5\n6\n7\n8\n9\nten"`,
+		want: ss(
+			`@ []`,
+			`  ...`,
+			`  7`,
+			`  8`,
+			`  9`,
+			`- 10`,
+			`+ ten`,
+		),
+	}}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, err := ReadJsonString(tc.a)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			b, err := ReadJsonString(tc.b)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			got := a.Diff(b).RenderHuman()
+			want := strings.Join(tc.want, "\n") + "\n"
+			if got != want {
+				t.Errorf("Want \n%v. Got \n%v", want, got)
+			}
+		})
+	}
+}
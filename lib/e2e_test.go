@@ -20,6 +20,11 @@ func TestDiffAndPatch(t *testing.T) {
 		`[{"a":2},{"a":3}]`,
 		`[{"a":1},{"a":1,"b":4},{"c":5}]`,
 		`[{"a":2},{"a":3,"b":4},{"c":5}]`)
+	checkDiffAndPatchSuccess(t,
+		`[1,6,3]`,
+		`[6]`,
+		`[1,6,3]`,
+		`[6]`)
 }
 
 func TestDiffAndPatchSet(t *testing.T) {
@@ -28,6 +33,41 @@ func TestDiffAndPatchSet(t *testing.T) {
 		`{"a":{"b" : ["3", "4", "5", "6"],"c" : ["2", "1"]}}`,
 		`{"a":{"b" : ["3", "4" ],"c" : ["2", "1"]}}`,
 		`{"a":{"b" : ["3", "4", "5", "6"],"c" : ["2", "1"]}}`)
+	checkDiffAndPatchSuccessSet(t,
+		`{"a":[1,2]}`,
+		`{"a":3}`,
+		`{"a":[1,2]}`,
+		`{"a":3}`)
+}
+
+func TestDiffAndPatchSetkeys(t *testing.T) {
+	err := checkDiffAndPatch(t, formatJd,
+		`[{"id":"a","x":1,"y":1},{"id":"b","x":1}]`,
+		`[{"id":"a","x":2,"y":2},{"id":"b","x":1}]`,
+		`[{"id":"b","x":1},{"id":"a","x":1,"y":1}]`,
+		`[{"id":"a","x":2,"y":2},{"id":"b","x":1}]`,
+		SET, Setkeys("id"))
+	if err != nil {
+		t.Errorf("Error round-tripping jd format: %v", err)
+	}
+	err = checkDiffAndPatch(t, formatJd,
+		`[{"a,b":1,"c":1}]`,
+		`[{"a,b":1,"c":2}]`,
+		`[{"a,b":1,"c":1}]`,
+		`[{"a,b":1,"c":2}]`,
+		SET, Setkeys("a,b"))
+	if err != nil {
+		t.Errorf("Error round-tripping jd format: %v", err)
+	}
+	err = checkDiffAndPatch(t, formatJd,
+		`[{"id":"a","x":1},{"id":"b"},{"id":"b"}]`,
+		`[{"id":"a","x":2,"y":3},{"id":"b"},{"id":"b"}]`,
+		`[{"id":"b"},{"id":"a","x":1}]`,
+		`[{"id":"b"},{"id":"a","x":2,"y":3}]`,
+		MULTISET, Setkeys("id"))
+	if err != nil {
+		t.Errorf("Error round-tripping jd format: %v", err)
+	}
 }
 
 func TestDiffAndPatchError(t *testing.T) {
@@ -107,10 +147,10 @@ func checkDiffAndPatch(t *testing.T, f format, a, b, c, expect string, metadata
 	var diff Diff
 	switch f {
 	case formatJd:
-		diffString := nodeA.Diff(nodeB).Render()
+		diffString := nodeA.Diff(nodeB, metadata...).Render()
 		diff, err = ReadDiffString(diffString)
 	case formatPatch:
-		patchString, err := nodeA.Diff(nodeB).RenderPatch()
+		patchString, err := nodeA.Diff(nodeB, metadata...).RenderPatch()
 		if err != nil {
 			return nil
 		}
@@ -125,7 +165,7 @@ func checkDiffAndPatch(t *testing.T, f format, a, b, c, expect string, metadata
 	if err != nil {
 		return err
 	}
-	if !actualNode.Equals(expectNode) {
+	if !actualNode.Equals(expectNode, metadata...) {
 		t.Errorf("actual = %v. Want %v.", actualNode, expectNode)
 	}
 	return nil
@@ -24,3 +24,23 @@ func ExampleJsonNode_Patch() {
 	// Output:
 	// ["foo","bar"]
 }
+
+func ExampleDiff_ApplyTo() {
+	type Deployment struct {
+		Name     string `json:"name"`
+		Replicas int    `json:"replicas"`
+	}
+	current := Deployment{Name: "web", Replicas: 1}
+	desired := Deployment{Name: "web", Replicas: 3}
+	a, _ := FromValue(current)
+	b, _ := FromValue(desired)
+	diff := a.Diff(b)
+	fmt.Print(diff.Render())
+	diff.ApplyTo(&current)
+	fmt.Println(current.Replicas)
+	// Output:
+	// @ ["replicas"]
+	// - 1
+	// + 3
+	// 3
+}
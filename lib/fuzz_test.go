@@ -0,0 +1,78 @@
+//go:build go1.18
+// +build go1.18
+
+package jd
+
+import (
+	"testing"
+)
+
+func FuzzDiffPatch(f *testing.F) {
+	f.Add(`{"a":[1,2,3]}`, `{"a":[3,1]}`)
+	f.Add(`[1,6,3]`, `[6]`)
+	f.Add(`[{"id":1,"a":2},{"id":2}]`, `[{"id":2,"a":3},{"id":3}]`)
+	f.Add(`[[1,1],2]`, `{"a":[2,[1]]}`)
+	f.Add(``, `null`)
+	f.Fuzz(func(t *testing.T, a, b string) {
+		for _, metadata := range roundtripMetadata {
+			checkRoundtrip(t, a, b, metadata)
+		}
+	})
+}
+
+func FuzzRandomDiffPatch(f *testing.F) {
+	for seed := int64(0); seed < 10; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		for _, metadata := range roundtripMetadata {
+			uniqueIds := getSetkeysMetadata(metadata) != nil
+			a, b := randomPair(seed, uniqueIds)
+			checkRoundtrip(t, a, b, metadata)
+		}
+	})
+}
+
+func FuzzPatchTranslation(f *testing.F) {
+	for seed := int64(0); seed < 10; seed++ {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		a, b := randomPair(seed, false)
+		checkPatchTranslation(t, a, b)
+	})
+}
+
+func FuzzReadDiff(f *testing.F) {
+	f.Add("@ [\"a\"]\n- 1\n+ 2\n")
+	f.Add("@ [[\"set\",\"setkeys=id\"],{}]\n- 1\n- 2\n+ 3\n")
+	f.Add("@ [\n")
+	f.Add("x\n")
+	f.Fuzz(func(t *testing.T, s string) {
+		d, err := ReadDiffString(s)
+		if err != nil {
+			return
+		}
+		rendered := d.Render()
+		again, err := ReadDiffString(rendered)
+		if err != nil {
+			t.Fatalf("Rendered diff doesn't read back: %v\n%v", err, rendered)
+		}
+		if again.Render() != rendered {
+			t.Fatalf("Rendered diff reads back as:\n%vWant:\n%v", again.Render(), rendered)
+		}
+	})
+}
+
+func FuzzReadPatch(f *testing.F) {
+	f.Add(`[{"op":"test","path":"/a","value":1},{"op":"remove","path":"/a","value":1},{"op":"add","path":"/a","value":[2]}]`)
+	f.Add(`[{"op":"add","path":"/a/-","value":{}}]`)
+	f.Fuzz(func(t *testing.T, s string) {
+		d, err := ReadPatchString(s)
+		if err != nil {
+			return
+		}
+		// Anything read as JSON Patch renders in jd format.
+		d.Render()
+	})
+}
@@ -2,16 +2,14 @@ package jd
 
 import (
 	"bytes"
-	"encoding/binary"
-	"hash/fnv"
+	"crypto/sha256"
 	"sort"
 )
 
 func hash(input []byte) [8]byte {
-	h := fnv.New64a()
-	h.Write(input)
+	h := sha256.Sum256(input)
 	var a [8]byte
-	binary.LittleEndian.PutUint64(a[:], h.Sum64())
+	copy(a[:], h[:8])
 	return a
 }
 
@@ -41,3 +39,135 @@ func (h hashCodes) combine() [8]byte {
 	}
 	return hash(b)
 }
+
+// nodeMap collects nodes by hash code. Nodes in the same bucket are
+// confirmed with Equals so colliding hash codes never conflate two
+// different values. When byIdent is set objects are keyed by their
+// identity (see jsonObject.ident) instead of their full content. Entries
+// are kept in insertion order so output never depends on hash codes.
+type nodeMap struct {
+	metadata []Metadata
+	byIdent  bool
+	buckets  map[[8]byte][]*nodeEntry
+	order    []*nodeEntry
+	size     int
+}
+
+type nodeEntry struct {
+	hash    [8]byte
+	node    JsonNode
+	count   int
+	deleted bool
+}
+
+func newNodeMap(metadata []Metadata, byIdent bool) *nodeMap {
+	return &nodeMap{
+		metadata: metadata,
+		byIdent:  byIdent,
+		buckets:  make(map[[8]byte][]*nodeEntry),
+	}
+}
+
+func (m *nodeMap) hash(n JsonNode) [8]byte {
+	if o, ok := n.(jsonObject); ok && m.byIdent {
+		return o.ident(m.metadata)
+	}
+	return n.hashCode(m.metadata)
+}
+
+func (m *nodeMap) equal(n1, n2 JsonNode) bool {
+	if m.byIdent {
+		o1, isObject1 := n1.(jsonObject)
+		o2, isObject2 := n2.(jsonObject)
+		if isObject1 && isObject2 {
+			return o1.identObject(m.metadata).Equals(o2.identObject(m.metadata), m.metadata...)
+		}
+	}
+	return n1.Equals(n2, m.metadata...)
+}
+
+// get returns the entry for n or nil if there is none.
+func (m *nodeMap) get(n JsonNode) *nodeEntry {
+	for _, e := range m.buckets[m.hash(n)] {
+		if m.equal(e.node, n) {
+			return e
+		}
+	}
+	return nil
+}
+
+// put records n, replacing the node of an existing entry and
+// incrementing its count.
+func (m *nodeMap) put(n JsonNode) *nodeEntry {
+	return m.putHashed(m.hash(n), n)
+}
+
+// putAll records each node in order. With PARALLEL metadata the hash
+// codes of many nodes are computed concurrently.
+func (m *nodeMap) putAll(nodes []JsonNode) {
+	if !parallel(m.metadata, len(nodes)) {
+		for _, n := range nodes {
+			m.put(n)
+		}
+		return
+	}
+	hashes := make([][8]byte, len(nodes))
+	forEachParallel(len(nodes), func(i int) {
+		hashes[i] = m.hash(nodes[i])
+	})
+	for i, n := range nodes {
+		m.putHashed(hashes[i], n)
+	}
+}
+
+func (m *nodeMap) putHashed(hc [8]byte, n JsonNode) *nodeEntry {
+	for _, e := range m.buckets[hc] {
+		if m.equal(e.node, n) {
+			e.node = n
+			e.count++
+			return e
+		}
+	}
+	e := &nodeEntry{
+		hash:  hc,
+		node:  n,
+		count: 1,
+	}
+	m.buckets[hc] = append(m.buckets[hc], e)
+	m.order = append(m.order, e)
+	m.size++
+	return e
+}
+
+func (m *nodeMap) delete(n JsonNode) {
+	hc := m.hash(n)
+	bucket := m.buckets[hc]
+	for i, e := range bucket {
+		if m.equal(e.node, n) {
+			bucket = append(bucket[:i:i], bucket[i+1:]...)
+			e.deleted = true
+			m.size--
+			break
+		}
+	}
+	if len(bucket) == 0 {
+		delete(m.buckets, hc)
+	} else {
+		m.buckets[hc] = bucket
+	}
+}
+
+func (m *nodeMap) len() int {
+	return m.size
+}
+
+// entries returns all entries in insertion order.
+func (m *nodeMap) entries() []*nodeEntry {
+	entries := make([]*nodeEntry, 0, m.size)
+	for _, e := range m.order {
+		if !e.deleted {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
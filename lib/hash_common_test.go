@@ -0,0 +1,51 @@
+package jd
+
+import (
+	"testing"
+)
+
+func TestNodeMapCollision(t *testing.T) {
+	m := newNodeMap(nil, false)
+	a := jsonString("a")
+	b := jsonString("b")
+	m.put(a)
+	// Force a hash collision by moving "a" into the bucket of "b".
+	hcA := m.hash(a)
+	hcB := m.hash(b)
+	m.buckets[hcB] = m.buckets[hcA]
+	m.buckets[hcB][0].hash = hcB
+	delete(m.buckets, hcA)
+	if e := m.get(b); e != nil {
+		t.Errorf("get(%v) = %v. Want nil.", b.Json(), e.node.Json())
+	}
+	m.put(b)
+	if m.len() != 2 {
+		t.Errorf("len() = %v. Want 2.", m.len())
+	}
+	if len(m.buckets[hcB]) != 2 {
+		t.Errorf("Want both nodes in the same bucket. Got %v.", len(m.buckets[hcB]))
+	}
+	entries := m.entries()
+	if len(entries) != 2 || !entries[0].node.Equals(a) || !entries[1].node.Equals(b) {
+		t.Errorf("entries() = %v. Want [a b] in insertion order.", entries)
+	}
+	m.delete(b)
+	if m.get(b) != nil || m.len() != 1 || !m.buckets[hcB][0].node.Equals(a) {
+		t.Errorf("delete(%v) removed the wrong node.", b.Json())
+	}
+}
+
+func TestNodeMapIdent(t *testing.T) {
+	metadata := m(SET, Setkeys("id"))
+	nm := newNodeMap(metadata, true)
+	o1, _ := ReadJsonString(`{"id":1,"a":1}`)
+	o2, _ := ReadJsonString(`{"id":1,"a":2}`)
+	o3, _ := ReadJsonString(`{"id":2,"a":1}`)
+	nm.put(o1)
+	if e := nm.get(o2); e == nil || !e.node.Equals(o1) {
+		t.Errorf("Want objects with the same id to match.")
+	}
+	if e := nm.get(o3); e != nil {
+		t.Errorf("Want objects with different ids not to match.")
+	}
+}
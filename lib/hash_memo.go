@@ -0,0 +1,101 @@
+package jd
+
+import (
+	"reflect"
+	"sync"
+)
+
+// hashMemo caches the hash codes of objects and arrays for the duration
+// of a single Diff or Equals, during which neither nodes nor metadata
+// change. It travels with the metadata so nested sets are hashed once
+// instead of at every level of comparison. It is safe for concurrent use
+// by PARALLEL diffs.
+type hashMemo struct {
+	mu     sync.Mutex
+	hashes map[memoKey]memoEntry
+}
+
+// memoKey identifies a node by its backing storage. The same storage
+// hashes differently as a list, set or multiset and an object has an
+// identity besides its hash code, so each is memoized under its own kind.
+type memoKey struct {
+	ptr  uintptr
+	len  int
+	kind byte
+}
+
+const (
+	memoObject   byte = 'o'
+	memoIdent    byte = 'i'
+	memoList     byte = 'l'
+	memoSet      byte = 's'
+	memoMultiset byte = 'm'
+)
+
+type memoEntry struct {
+	// The node is kept so its storage can't be reused by another node
+	// while memoized.
+	node JsonNode
+	hash [8]byte
+}
+
+func (*hashMemo) is_metadata() {}
+
+func (*hashMemo) string() string {
+	return "hashmemo"
+}
+
+// withHashMemo returns metadata carrying a hash memo, adding one if there
+// is none yet.
+func withHashMemo(metadata []Metadata) []Metadata {
+	if getHashMemo(metadata) != nil {
+		return metadata
+	}
+	m := make([]Metadata, len(metadata), len(metadata)+1)
+	copy(m, metadata)
+	return append(m, &hashMemo{
+		hashes: make(map[memoKey]memoEntry),
+	})
+}
+
+func getHashMemo(metadata []Metadata) *hashMemo {
+	for _, o := range metadata {
+		if m, ok := o.(*hashMemo); ok {
+			return m
+		}
+	}
+	return nil
+}
+
+// memoHash returns the memoized hash code of n, stored in the given map
+// or slice, or computes and memoizes it. Without a memo in the metadata
+// it just computes it.
+func memoHash(n JsonNode, storage interface{}, kind byte, metadata []Metadata, compute func() [8]byte) [8]byte {
+	m := getHashMemo(metadata)
+	if m == nil {
+		return compute()
+	}
+	v := reflect.ValueOf(storage)
+	if v.Len() == 0 {
+		return compute()
+	}
+	key := memoKey{
+		ptr:  v.Pointer(),
+		len:  v.Len(),
+		kind: kind,
+	}
+	m.mu.Lock()
+	e, ok := m.hashes[key]
+	m.mu.Unlock()
+	if ok {
+		return e.hash
+	}
+	h := compute()
+	m.mu.Lock()
+	m.hashes[key] = memoEntry{
+		node: n,
+		hash: h,
+	}
+	m.mu.Unlock()
+	return h
+}
@@ -0,0 +1,93 @@
+package jd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// nestedSets returns arrays of arrays nested depth levels deep with width
+// elements at each level.
+func nestedSets(depth, width, seed int) string {
+	if depth == 0 {
+		return fmt.Sprintf(`{"id":%v}`, seed)
+	}
+	elements := make([]string, width)
+	for i := range elements {
+		elements[i] = nestedSets(depth-1, width, seed*width+i)
+	}
+	return "[" + strings.Join(elements, ",") + "]"
+}
+
+func TestHashMemo(t *testing.T) {
+	n, err := ReadJsonString(nestedSets(3, 4, 0))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for _, metadata := range [][]Metadata{
+		nil,
+		m(SET),
+		m(MULTISET),
+		m(SET, Setkeys("id")),
+	} {
+		d := dispatch(n, metadata)
+		want := d.hashCode(metadata)
+		memo := withHashMemo(metadata)
+		if got := d.hashCode(memo); got != want {
+			t.Errorf("%v: memoized hash %x. Want %x.", metadata, got, want)
+		}
+		// Again from the memo.
+		if got := d.hashCode(memo); got != want {
+			t.Errorf("%v: second memoized hash %x. Want %x.", metadata, got, want)
+		}
+		if len(getHashMemo(memo).hashes) == 0 {
+			t.Errorf("%v: want memoized hashes.", metadata)
+		}
+	}
+}
+
+func TestHashMemoKinds(t *testing.T) {
+	a := jsonArray{jsonNumber(2), jsonNumber(1), jsonNumber(3)}
+	memo := withHashMemo(nil)
+	if jsonList(a).hashCode(memo) != jsonList(a).hashCode(nil) {
+		t.Errorf("Want memoized list hash to match.")
+	}
+	if jsonMultiset(a).hashCode(memo) != jsonMultiset(a).hashCode(nil) {
+		t.Errorf("Want memoized multiset hash to match.")
+	}
+}
+
+func TestWithHashMemo(t *testing.T) {
+	metadata := make([]Metadata, 1, 2)
+	metadata[0] = SET
+	memo := withHashMemo(metadata)
+	if len(metadata) != 1 || cap(metadata) != 2 {
+		t.Errorf("Want the given metadata unchanged.")
+	}
+	if again := withHashMemo(memo); getHashMemo(again) != getHashMemo(memo) {
+		t.Errorf("Want the existing memo reused.")
+	}
+	if !checkMetadata(SET, memo) {
+		t.Errorf("Want the given metadata kept.")
+	}
+}
+
+func BenchmarkNestedSetEquals(b *testing.B) {
+	n1, _ := ReadJsonString(nestedSets(4, 6, 0))
+	n2, _ := ReadJsonString(nestedSets(4, 6, 0))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !n1.Equals(n2, SET) {
+			b.Fatalf("Want equal.")
+		}
+	}
+}
+
+func BenchmarkNestedSetDiff(b *testing.B) {
+	n1, _ := ReadJsonString(nestedSets(4, 6, 0))
+	n2, _ := ReadJsonString(nestedSets(4, 6, 1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n1.Diff(n2, SET)
+	}
+}
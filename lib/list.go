@@ -37,70 +37,96 @@ func (l1 jsonList) Equals(n JsonNode, metadata ...Metadata) bool {
 }
 
 func (l jsonList) hashCode(metadata []Metadata) [8]byte {
-	b := make([]byte, 0, len(l)*8)
-	for _, n := range l {
-		h := n.hashCode(metadata)
-		b = append(b, h[:]...)
-	}
-	return hash(b)
+	return memoHash(l, []JsonNode(l), memoList, metadata, func() [8]byte {
+		b := make([]byte, 0, len(l)*8)
+		for _, n := range l {
+			h := n.hashCode(metadata)
+			b = append(b, h[:]...)
+		}
+		return hash(b)
+	})
 }
 
 func (l jsonList) Diff(n JsonNode, metadata ...Metadata) Diff {
-	return l.diff(n, make(path, 0), metadata)
+	metadata = withHashMemo(metadata)
+	return l.diff(n, make(Path, 0), metadata)
+}
+
+func (a1 jsonList) diff(n JsonNode, path Path, metadata []Metadata) Diff {
+	return collectDiff(func(emit emitFunc) error {
+		return a1.diffTo(n, path, metadata, emit)
+	})
 }
 
-func (a1 jsonList) diff(n JsonNode, path path, metadata []Metadata) Diff {
-	d := make(Diff, 0)
+func (a1 jsonList) diffTo(n JsonNode, path Path, metadata []Metadata, emit emitFunc) error {
 	a2, ok := n.(jsonList)
 	if !ok {
 		// Different types
 		e := DiffElement{
 			Path:      path.clone(),
-			OldValues: nodeList(a1),
+			OldValues: nodeList(jsonArray(a1)),
 			NewValues: nodeList(n),
 		}
-		return append(d, e)
+		return emit(e)
 	}
 	maxLen := len(a1)
 	if len(a1) < len(a2) {
 		maxLen = len(a2)
 	}
-	for i := 0; i < maxLen; i++ {
-		a1Has := i < len(a1)
-		a2Has := i < len(a2)
-		subPath := append(path, jsonNumber(i))
-		if a1Has && a2Has {
+	minLen := len(a1) + len(a2) - maxLen
+	var subDiffs []Diff
+	if parallel(metadata, minLen) {
+		subDiffs = make([]Diff, minLen)
+		forEachParallel(minLen, func(i int) {
 			n1 := dispatch(a1[i], metadata)
 			n2 := dispatch(a2[i], metadata)
-			subDiff := n1.diff(n2, subPath, metadata)
-			d = append(d, subDiff...)
+			p := append(path.clone(), jsonNumber(i))
+			subDiffs[i] = n1.diff(n2, p, metadata)
+		})
+	}
+	for i := 0; i < minLen; i++ {
+		var err error
+		if subDiffs != nil {
+			err = emitAll(subDiffs[i], emit)
+		} else {
+			n1 := dispatch(a1[i], metadata)
+			n2 := dispatch(a2[i], metadata)
+			err = diffTo(n1, n2, append(path, jsonNumber(i)), metadata, emit)
+		}
+		if err != nil {
+			return err
 		}
-		if a1Has && !a2Has {
-			e := DiffElement{
-				Path:      subPath.clone(),
-				OldValues: nodeList(a1[i]),
-				NewValues: nodeList(),
-			}
-			d = append(d, e)
+	}
+	// Only the last element of an array can be removed, so removals go
+	// from the end.
+	for i := len(a1) - 1; i >= minLen; i-- {
+		e := DiffElement{
+			Path:      append(path, jsonNumber(i)).clone(),
+			OldValues: nodeList(a1[i]),
+			NewValues: nodeList(),
+		}
+		if err := emit(e); err != nil {
+			return err
+		}
+	}
+	for i := minLen; i < len(a2); i++ {
+		e := DiffElement{
+			Path:      append(path, jsonNumber(-1)).clone(),
+			OldValues: nodeList(),
+			NewValues: nodeList(a2[i]),
 		}
-		if !a1Has && a2Has {
-			appendPath := append(path, jsonNumber(-1))
-			e := DiffElement{
-				Path:      appendPath.clone(),
-				OldValues: nodeList(),
-				NewValues: nodeList(a2[i]),
-			}
-			d = append(d, e)
+		if err := emit(e); err != nil {
+			return err
 		}
 	}
-	return d
+	return nil
 }
 
 func (l jsonList) Patch(d Diff) (JsonNode, error) {
 	return patchAll(l, d)
 }
 
-func (l jsonList) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (l jsonList) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
 
 	if len(oldValues) > 1 || len(newValues) > 1 {
 		return patchErrNonSetDiff(oldValues, newValues, pathBehind)
@@ -18,67 +18,85 @@ func (a jsonMultiset) Yaml(metadata ...Metadata) string {
 }
 
 func (a jsonMultiset) raw(metadata []Metadata) interface{} {
-	return jsonArray(a).raw(metadata)
+	return jsonArray(sortNodes(a, metadata)).raw(metadata)
 }
 
 func (a1 jsonMultiset) Equals(n JsonNode, metadata ...Metadata) bool {
-	a2, ok := n.(jsonMultiset)
+	metadata = withHashMemo(metadata)
+	n2 := dispatch(n, metadata)
+	a2, ok := n2.(jsonMultiset)
 	if !ok {
 		return false
 	}
 	if len(a1) != len(a2) {
 		return false
 	}
-	if a1.hashCode(metadata) == a2.hashCode(metadata) {
-		return true
-	} else {
+	if a1.hashCode(metadata) != a2.hashCode(metadata) {
+		return false
+	}
+	// Same hash codes. Confirm each value is present as many times.
+	a1Map := a1.nodeMap(metadata, false)
+	a2Map := a2.nodeMap(metadata, false)
+	if a1Map.len() != a2Map.len() {
 		return false
 	}
+	for _, e1 := range a1Map.entries() {
+		e2 := a2Map.get(e1.node)
+		if e2 == nil || e2.count != e1.count {
+			return false
+		}
+	}
+	return true
+}
+
+func (a jsonMultiset) nodeMap(metadata []Metadata, byIdent bool) *nodeMap {
+	aMap := newNodeMap(metadata, byIdent)
+	aMap.putAll(a)
+	return aMap
 }
 
 func (a jsonMultiset) hashCode(metadata []Metadata) [8]byte {
-	h := make(hashCodes, 0, len(a))
-	for _, v := range a {
-		h = append(h, v.hashCode(metadata))
-	}
-	sort.Sort(h)
-	b := make([]byte, 0, len(a)*8)
-	for _, c := range h {
-		b = append(b, c[:]...)
-	}
-	return hash(b)
+	return memoHash(a, []JsonNode(a), memoMultiset, metadata, func() [8]byte {
+		h := make(hashCodes, 0, len(a))
+		for _, v := range a {
+			h = append(h, v.hashCode(metadata))
+		}
+		sort.Sort(h)
+		b := make([]byte, 0, len(a)*8)
+		for _, c := range h {
+			b = append(b, c[:]...)
+		}
+		return hash(b)
+	})
 }
 
 func (a jsonMultiset) Diff(n JsonNode, metadata ...Metadata) Diff {
+	metadata = withHashMemo(metadata)
 	return a.diff(n, nil, metadata)
 }
 
-func (a1 jsonMultiset) diff(n JsonNode, path path, metadata []Metadata) Diff {
+func (a1 jsonMultiset) diff(n JsonNode, path Path, metadata []Metadata) Diff {
 	d := make(Diff, 0)
 	a2, ok := n.(jsonMultiset)
 	if !ok {
 		// Different types
 		e := DiffElement{
 			Path:      path.clone(),
-			OldValues: nodeList(a1),
+			OldValues: nodeList(jsonArray(a1)),
 			NewValues: nodeList(n),
 		}
 		return append(d, e)
 	}
-	a1Counts := make(map[[8]byte]int)
-	a1Map := make(map[[8]byte]JsonNode)
-	for _, v := range a1 {
-		hc := v.hashCode(metadata)
-		a1Counts[hc]++
-		a1Map[hc] = v
-	}
-	a2Counts := make(map[[8]byte]int)
-	a2Map := make(map[[8]byte]JsonNode)
-	for _, v := range a2 {
-		hc := v.hashCode(metadata)
-		a2Counts[hc]++
-		a2Map[hc] = v
+	a1Map := a1.nodeMap(metadata, false)
+	a2Map := a2.nodeMap(metadata, false)
+	// Objects identified by set keys exactly once on each side are
+	// diffed in place rather than removed and added whole.
+	var a1Idents, a2Idents *nodeMap
+	if getSetkeysMetadata(metadata) != nil {
+		a1Idents = a1.nodeMap(metadata, true)
+		a2Idents = a2.nodeMap(metadata, true)
 	}
+	paired := newNodeMap(metadata, false)
 	// TODO: cast directly to jsonObject when jsonObject drops idKeys.
 	o, _ := NewJsonNode(map[string]interface{}{})
 	e := DiffElement{
@@ -86,40 +104,39 @@ func (a1 jsonMultiset) diff(n JsonNode, path path, metadata []Metadata) Diff {
 		OldValues: nodeList(),
 		NewValues: nodeList(),
 	}
-	a1Hashes := make(hashCodes, 0)
-	for hc := range a1Counts {
-		a1Hashes = append(a1Hashes, hc)
-	}
-	sort.Sort(a1Hashes)
-	a2Hashes := make(hashCodes, 0)
-	for hc := range a2Counts {
-		a2Hashes = append(a2Hashes, hc)
-	}
-	sort.Sort(a2Hashes)
-	for _, hc := range a1Hashes {
-		a1Count := a1Counts[hc]
-		a2Count, ok := a2Counts[hc]
-		if !ok {
-			a2Count = 0
-		}
-		removed := a1Count - a2Count
-		if removed > 0 {
-			for i := 0; i < removed; i++ {
-				e.OldValues = append(e.OldValues, a1Map[hc])
+	for _, e1 := range a1Map.entries() {
+		a2Count := 0
+		if e2 := a2Map.get(e1.node); e2 != nil {
+			a2Count = e2.count
+		}
+		removed := e1.count - a2Count
+		if o1, ok := e1.node.(jsonObject); ok && removed > 0 && a1Idents != nil {
+			id1 := a1Idents.get(o1)
+			id2 := a2Idents.get(o1)
+			if id1.count == 1 && id2 != nil && id2.count == 1 {
+				// Sub diff objects with same identity.
+				o2 := id2.node.(jsonObject)
+				p := path.appendIndex(o1.identObject(metadata), metadata)
+				d = append(d, o1.diff(o2, p, metadata)...)
+				paired.put(o2)
+				continue
 			}
 		}
+		for i := 0; i < removed; i++ {
+			e.OldValues = append(e.OldValues, e1.node)
+		}
 	}
-	for _, hc := range a2Hashes {
-		a2Count := a2Counts[hc]
-		a1Count, ok := a1Counts[hc]
-		if !ok {
-			a1Count = 0
+	for _, e2 := range a2Map.entries() {
+		a1Count := 0
+		if e1 := a1Map.get(e2.node); e1 != nil {
+			a1Count = e1.count
 		}
-		added := a2Count - a1Count
-		if added > 0 {
-			for i := 0; i < added; i++ {
-				e.NewValues = append(e.NewValues, a2Map[hc])
-			}
+		added := e2.count - a1Count
+		if paired.get(e2.node) != nil {
+			continue
+		}
+		for i := 0; i < added; i++ {
+			e.NewValues = append(e.NewValues, e2.node)
 		}
 	}
 	if len(e.OldValues) > 0 || len(e.NewValues) > 0 {
@@ -132,7 +149,7 @@ func (a jsonMultiset) Patch(d Diff) (JsonNode, error) {
 	return patchAll(a, d)
 }
 
-func (a jsonMultiset) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (a jsonMultiset) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
 	// Base case
 	if len(pathAhead) == 0 {
 		if len(oldValues) > 1 || len(newValues) > 1 {
@@ -146,52 +163,62 @@ func (a jsonMultiset) patch(pathBehind, pathAhead path, oldValues, newValues []J
 		return newValue, nil
 	}
 	// Unrolled recursive case
-	n, metadata, _ := pathAhead.next()
+	n, metadata, rest := pathAhead.next()
 	o, ok := n.(jsonObject)
 	if !ok {
 		return nil, fmt.Errorf(
 			"Invalid path element %v. Expected map[string]interface{}.", n)
 	}
+	if len(rest) > 0 {
+		// Recurse into a specific object.
+		keys := pathIdentKeys(o, metadata)
+		found := -1
+		for i, v := range a {
+			if e, ok := v.(jsonObject); ok && e.hasPathIdent(o, keys) {
+				if found >= 0 {
+					return nil, fmt.Errorf(
+						"Invalid diff. Expected one object with id %v but found more.",
+						o.Json(metadata...))
+				}
+				found = i
+			}
+		}
+		if found < 0 {
+			return nil, fmt.Errorf(
+				"Invalid diff. Expected object with id %v but found none.",
+				o.Json(metadata...))
+		}
+		patched, err := a[found].patch(append(pathBehind, n), rest, oldValues, newValues)
+		if err != nil {
+			return nil, err
+		}
+		a[found] = patched
+		return a, nil
+	}
 	if len(o.properties) != 0 {
 		return nil, fmt.Errorf(
 			"Invalid path element %v. Expected empty object.", n)
 	}
-	aCounts := make(map[[8]byte]int)
-	aMap := make(map[[8]byte]JsonNode)
-	for _, v := range a {
-		hc := v.hashCode(metadata)
-		aCounts[hc]++
-		aMap[hc] = v
-	}
+	// Remove values in place and append additions to keep the order.
+	toDelete := newNodeMap(metadata, false)
 	for _, v := range oldValues {
-		hc := v.hashCode(metadata)
-		aCounts[hc]--
-		aMap[hc] = v
+		toDelete.put(v)
 	}
-	for hc, count := range aCounts {
-		if count < 0 {
-			return nil, fmt.Errorf(
-				"Invalid diff. Expected %v at %v but found nothing.",
-				aMap[hc].Json(metadata...), pathBehind)
+	newValue := make(jsonMultiset, 0, len(a)+len(newValues))
+	for _, v := range a {
+		if e := toDelete.get(v); e != nil && e.count > 0 {
+			e.count--
+			continue
 		}
+		newValue = append(newValue, v)
 	}
-	for _, v := range newValues {
-		hc := v.hashCode(metadata)
-		aCounts[hc]++
-		aMap[hc] = v
-	}
-	aHashes := make(hashCodes, 0)
-	for hc := range aCounts {
-		if aCounts[hc] > 0 {
-			for i := 0; i < aCounts[hc]; i++ {
-				aHashes = append(aHashes, hc)
-			}
+	for _, e := range toDelete.entries() {
+		if e.count > 0 {
+			return nil, fmt.Errorf(
+				"Invalid diff. Expected %v at %v but found nothing.",
+				e.node.Json(metadata...), pathBehind)
 		}
 	}
-	sort.Sort(aHashes)
-	newValue := make(jsonMultiset, 0)
-	for _, hc := range aHashes {
-		newValue = append(newValue, aMap[hc])
-	}
+	newValue = append(newValue, newValues...)
 	return newValue, nil
 }
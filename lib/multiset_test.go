@@ -7,40 +7,45 @@ import (
 func TestMultisetJson(t *testing.T) {
 	cases := []struct {
 		name     string
-		metadata Metadata
+		metadata []Metadata
 		given    string
 		want     string
 	}{{
 		name:     "empty mulitset",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		given:    `[]`,
 		want:     `[]`,
 	}, {
 		name:     "empty multiset with space",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		given:    ` [ ] `,
 		want:     `[]`,
 	}, {
 		name:     "ordered multiset",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		given:    `[1,2,3]`,
 		want:     `[1,2,3]`,
 	}, {
 		name:     "ordered multiset with space",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		given:    ` [1, 2, 3] `,
 		want:     `[1,2,3]`,
 	}, {
 		name:     "multset with multiple duplicates",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		given:    `[1,1,1]`,
 		want:     `[1,1,1]`,
+	}, {
+		name:     "sorted multiset",
+		metadata: m(MULTISET, SORT),
+		given:    `[2,1,2,"a"]`,
+		want:     `[1,2,2,"a"]`,
 	}}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			ctx := newTestContext(t).
-				withMetadata(c.metadata)
+				withMetadata(c.metadata...)
 			checkJson(ctx, c.given, c.want)
 		})
 	}
@@ -134,19 +139,19 @@ func TestMultisetNotEquals(t *testing.T) {
 func TestMultisetDiff(t *testing.T) {
 	cases := []struct {
 		name     string
-		metadata Metadata
+		metadata []Metadata
 		a        string
 		b        string
 		want     []string
 	}{{
 		name:     "two empty multisets",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `[]`,
 		b:        `[]`,
 		want:     ss(),
 	}, {
 		name:     "two multisets with different numbers",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `[1]`,
 		b:        `[1,2]`,
 		want: ss(
@@ -155,13 +160,13 @@ func TestMultisetDiff(t *testing.T) {
 		),
 	}, {
 		name:     "two multisets with the same number",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `[1,2]`,
 		b:        `[1,2]`,
 		want:     ss(),
 	}, {
 		name:     "adding two numbers",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `[1]`,
 		b:        `[1,2,2]`,
 		want: ss(
@@ -171,7 +176,7 @@ func TestMultisetDiff(t *testing.T) {
 		),
 	}, {
 		name:     "removing a number",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `[1,2,3]`,
 		b:        `[1,3]`,
 		want: ss(
@@ -180,7 +185,7 @@ func TestMultisetDiff(t *testing.T) {
 		),
 	}, {
 		name:     "replacing one object with another",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `[{"a":1}]`,
 		b:        `[{"a":2}]`,
 		want: ss(
@@ -190,7 +195,7 @@ func TestMultisetDiff(t *testing.T) {
 		),
 	}, {
 		name:     "replacing two objects with one object",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `[{"a":1},{"a":1}]`,
 		b:        `[{"a":2}]`,
 		want: ss(
@@ -201,19 +206,19 @@ func TestMultisetDiff(t *testing.T) {
 		),
 	}, {
 		name:     "replacing three strings repeated with one string",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `["foo","foo","bar"]`,
 		b:        `["baz"]`,
 		want: ss(
 			`@ [["multiset"],{}]`,
-			`- "bar"`,
 			`- "foo"`,
 			`- "foo"`,
+			`- "bar"`,
 			`+ "baz"`,
 		),
 	}, {
 		name:     "replacing one string with three repeated",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `["foo"]`,
 		b:        `["bar","baz","bar"]`,
 		want: ss(
@@ -223,9 +228,50 @@ func TestMultisetDiff(t *testing.T) {
 			`+ "bar"`,
 			`+ "baz"`,
 		),
+	}, {
+		name: "changing a property of an object with set keys",
+		metadata: m(
+			MULTISET,
+			Setkeys("id"),
+		),
+		a: `[{"id":"foo","bar":1},{"id":"baz"},{"id":"baz"}]`,
+		b: `[{"id":"foo","bar":2},{"id":"baz"},{"id":"baz"}]`,
+		want: ss(
+			`@ [["multiset","setkeys=id"],{"id":"foo"},"bar"]`,
+			`- 1`,
+			`+ 2`,
+		),
+	}, {
+		name: "changing objects with repeated set keys",
+		metadata: m(
+			MULTISET,
+			Setkeys("id"),
+		),
+		a: `[{"id":"foo","bar":1},{"id":"foo","bar":1}]`,
+		b: `[{"id":"foo","bar":2},{"id":"foo","bar":1}]`,
+		want: ss(
+			`@ [["multiset","setkeys=id"],{}]`,
+			`- {"bar":1,"id":"foo"}`,
+			`+ {"bar":2,"id":"foo"}`,
+		),
+	}, {
+		name: "changing and adding objects with set keys",
+		metadata: m(
+			MULTISET,
+			Setkeys("id"),
+		),
+		a: `[{"id":"foo","bar":1}]`,
+		b: `[{"id":"foo","bar":2},{"id":"baz"}]`,
+		want: ss(
+			`@ [["multiset","setkeys=id"],{"id":"foo"},"bar"]`,
+			`- 1`,
+			`+ 2`,
+			`@ [["multiset","setkeys=id"],{}]`,
+			`+ {"id":"baz"}`,
+		),
 	}, {
 		name:     "replacing multiset with array",
-		metadata: MULTISET,
+		metadata: m(MULTISET),
 		a:        `{}`,
 		b:        `[]`,
 		want: ss(
@@ -238,7 +284,7 @@ func TestMultisetDiff(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			ctx := newTestContext(t).
-				withMetadata(c.metadata)
+				withMetadata(c.metadata...)
 			checkDiff(ctx, c.a, c.b, c.want...)
 		})
 	}
@@ -318,9 +364,9 @@ func TestMultisetPatch(t *testing.T) {
 		given:    `["foo","foo","bar"]`,
 		patch: ss(
 			`@ [["multiset"],{}]`,
-			`- "bar"`,
 			`- "foo"`,
 			`- "foo"`,
+			`- "bar"`,
 			`+ "baz"`,
 		),
 		want: `["baz"]`,
@@ -336,6 +382,16 @@ func TestMultisetPatch(t *testing.T) {
 			`+ "baz"`,
 		),
 		want: `["bar","baz","bar"]`,
+	}, {
+		name:     "patch property of object by set keys",
+		metadata: MULTISET,
+		given:    `[{"id":"foo","bar":1},{"id":"baz"},{"id":"baz"}]`,
+		patch: ss(
+			`@ [["multiset","setkeys=id"],{"id":"foo"},"bar"]`,
+			`- 1`,
+			`+ 2`,
+		),
+		want: `[{"id":"foo","bar":2},{"id":"baz"},{"id":"baz"}]`,
 	}, {
 		name:     "replace multiset with array",
 		metadata: MULTISET,
@@ -358,6 +414,42 @@ func TestMultisetPatch(t *testing.T) {
 	}
 }
 
+func TestMultisetPatchOrder(t *testing.T) {
+	cases := []struct {
+		name     string
+		metadata []Metadata
+		given    string
+		patch    []string
+		want     string
+	}{{
+		name:     "removals in place and additions appended",
+		metadata: m(MULTISET),
+		given:    `[3,1,2,1]`,
+		patch: ss(
+			`@ [["multiset"],{}]`,
+			`- 1`,
+			`+ 0`,
+		),
+		want: `[3,2,1,0]`,
+	}, {
+		name:     "sorted additions",
+		metadata: m(MULTISET, SORT),
+		given:    `[3,1,2,1]`,
+		patch: ss(
+			`@ [["multiset"],{}]`,
+			`- 1`,
+			`+ 0`,
+		),
+		want: `[0,1,2,3]`,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			checkPatchJson(t, c.given, c.want, c.metadata, c.patch...)
+		})
+	}
+}
+
 func TestMultisetPatchError(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -389,6 +481,24 @@ func TestMultisetPatchError(t *testing.T) {
 			`@ []`,
 			`- {}`,
 		),
+	}, {
+		name:     "patch object by ambiguous set keys",
+		metadata: MULTISET,
+		given:    `[{"id":"foo","bar":1},{"id":"foo","bar":1}]`,
+		patch: ss(
+			`@ [["multiset","setkeys=id"],{"id":"foo"},"bar"]`,
+			`- 1`,
+			`+ 2`,
+		),
+	}, {
+		name:     "patch missing object by set keys",
+		metadata: MULTISET,
+		given:    `[{"id":"foo","bar":1}]`,
+		patch: ss(
+			`@ [["multiset","setkeys=id"],{"id":"baz"},"bar"]`,
+			`- 1`,
+			`+ 2`,
+		),
 	}}
 
 	for _, c := range cases {
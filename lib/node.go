@@ -1,8 +1,8 @@
 package jd
 
 import (
-	"errors"
-	"fmt"
+	"encoding/json"
+	"reflect"
 )
 
 type JsonNode interface {
@@ -12,28 +12,30 @@ type JsonNode interface {
 	Equals(n JsonNode, metadata ...Metadata) bool
 	hashCode(metadata []Metadata) [8]byte
 	Diff(n JsonNode, metadata ...Metadata) Diff
-	diff(n JsonNode, p path, metadata []Metadata) Diff
+	diff(n JsonNode, p Path, metadata []Metadata) Diff
 	Patch(d Diff) (JsonNode, error)
-	patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error)
+	patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error)
 }
 
+// NewJsonNode converts a Go value into a JsonNode. It accepts the values
+// produced by common decoders (encoding/json, YAML, TOML, msgpack)
+// including typed numbers, json.Number, typed slices and maps, and maps
+// with scalar keys. Anything else is converted with FromValue.
 func NewJsonNode(n interface{}) (JsonNode, error) {
 	switch t := n.(type) {
+	case JsonNode:
+		return t, nil
 	case map[string]interface{}:
 		m := jsonObject{
 			properties: make(map[string]JsonNode),
 			idKeys:     make(map[string]bool),
 		}
 		for k, v := range t {
-			n, ok := v.(JsonNode)
-			if !ok {
-				e, err := NewJsonNode(v)
-				if err != nil {
-					return nil, err
-				}
-				n = e
+			e, err := NewJsonNode(v)
+			if err != nil {
+				return nil, err
 			}
-			m.properties[k] = n
+			m.properties[k] = e
 		}
 		return m, nil
 	case map[interface{}]interface{}:
@@ -42,35 +44,37 @@ func NewJsonNode(n interface{}) (JsonNode, error) {
 			idKeys:     make(map[string]bool),
 		}
 		for k, v := range t {
-			s, ok := k.(string)
-			if !ok {
-				return nil, fmt.Errorf("Unsupported key type %T", k)
+			s, err := mapKey(reflect.ValueOf(k))
+			if err != nil {
+				return nil, err
 			}
-			if _, ok := v.(JsonNode); !ok {
-				e, err := NewJsonNode(v)
-				if err != nil {
-					return nil, err
-				}
-				m.properties[s] = e
+			e, err := NewJsonNode(v)
+			if err != nil {
+				return nil, err
 			}
+			m.properties[s] = e
 		}
 		return m, nil
 	case []interface{}:
 		l := make(jsonArray, len(t))
 		for i, v := range t {
-			if _, ok := v.(JsonNode); !ok {
-				e, err := NewJsonNode(v)
-				if err != nil {
-					return nil, err
-				}
-				l[i] = e
+			e, err := NewJsonNode(v)
+			if err != nil {
+				return nil, err
 			}
+			l[i] = e
 		}
 		return l, nil
 	case float64:
 		return jsonNumber(t), nil
 	case int:
 		return jsonNumber(t), nil
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return jsonNumber(f), nil
 	case string:
 		return jsonString(t), nil
 	case bool:
@@ -78,7 +82,7 @@ func NewJsonNode(n interface{}) (JsonNode, error) {
 	case nil:
 		return jsonNull(nil), nil
 	default:
-		return nil, errors.New(fmt.Sprintf("Unsupported type %T", t))
+		return fromValue(reflect.ValueOf(t))
 	}
 }
 
@@ -0,0 +1,199 @@
+package jd
+
+import (
+	"sort"
+)
+
+// Kind is the JSON type of a node.
+type Kind int
+
+const (
+	// Void is the absence of a value, e.g. an empty document.
+	Void Kind = iota
+	Null
+	Bool
+	Number
+	String
+	Object
+	Array
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Void:
+		return "void"
+	case Null:
+		return "null"
+	case Bool:
+		return "bool"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Object:
+		return "object"
+	case Array:
+		return "array"
+	}
+	return "unknown"
+}
+
+// KindOf returns the JSON type of a node. Arrays are Array regardless of
+// whether they are treated as lists, sets or multisets.
+func KindOf(n JsonNode) Kind {
+	switch n.(type) {
+	case jsonNull:
+		return Null
+	case jsonBool:
+		return Bool
+	case jsonNumber:
+		return Number
+	case jsonString:
+		return String
+	case jsonObject:
+		return Object
+	case jsonArray, jsonList, jsonSet, jsonMultiset:
+		return Array
+	}
+	return Void
+}
+
+// Keys returns the sorted keys of an object or nil for any other node.
+func Keys(n JsonNode) []string {
+	o, ok := n.(jsonObject)
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(o.properties))
+	for k := range o.properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Field returns the value of an object's key.
+func Field(n JsonNode, key string) (JsonNode, bool) {
+	o, ok := n.(jsonObject)
+	if !ok {
+		return nil, false
+	}
+	v, ok := o.properties[key]
+	return v, ok
+}
+
+// Len returns the number of elements of an array or properties of an
+// object. It returns 0 for any other node.
+func Len(n JsonNode) int {
+	if o, ok := n.(jsonObject); ok {
+		return len(o.properties)
+	}
+	a, _ := arrayElements(n)
+	return len(a)
+}
+
+// Index returns the i-th element of an array.
+func Index(n JsonNode, i int) (JsonNode, bool) {
+	a, ok := arrayElements(n)
+	if !ok || i < 0 || i >= len(a) {
+		return nil, false
+	}
+	return a[i], true
+}
+
+func arrayElements(n JsonNode) ([]JsonNode, bool) {
+	switch a := n.(type) {
+	case jsonArray:
+		return a, true
+	case jsonList:
+		return a, true
+	case jsonSet:
+		return a, true
+	case jsonMultiset:
+		return a, true
+	}
+	return nil, false
+}
+
+// StringValue returns the value of a string node.
+func StringValue(n JsonNode) (string, bool) {
+	s, ok := n.(jsonString)
+	return string(s), ok
+}
+
+// NumberValue returns the value of a number node.
+func NumberValue(n JsonNode) (float64, bool) {
+	f, ok := n.(jsonNumber)
+	return float64(f), ok
+}
+
+// BoolValue returns the value of a boolean node.
+func BoolValue(n JsonNode) (bool, bool) {
+	b, ok := n.(jsonBool)
+	return bool(b), ok
+}
+
+// Get returns the node at a path in the format of DiffElement.Path.
+// Strings access object keys and numbers access array indices. Objects
+// access an element of a set or multiset by its identity and an empty
+// object accesses the array itself. Path metadata selects set keys or
+// descends into embedded JSON or YAML strings.
+func Get(n JsonNode, p Path) (JsonNode, bool) {
+	rest := p
+	for len(rest) > 0 {
+		if format, ok := embeddedFormat(rest); ok {
+			s, ok := n.(jsonString)
+			if !ok {
+				return nil, false
+			}
+			e, f := parseEmbedded(string(s))
+			if e == nil || f != format {
+				return nil, false
+			}
+			n, rest = e, rest[1:]
+			continue
+		}
+		var pe JsonNode
+		var metadata []Metadata
+		pe, metadata, rest = rest.next()
+		switch pe := pe.(type) {
+		case jsonString:
+			v, ok := Field(n, string(pe))
+			if !ok {
+				return nil, false
+			}
+			n = v
+		case jsonNumber:
+			v, ok := Index(n, int(pe))
+			if !ok {
+				return nil, false
+			}
+			n = v
+		case jsonObject:
+			a, ok := arrayElements(n)
+			if !ok {
+				return nil, false
+			}
+			if len(pe.properties) == 0 {
+				// The set itself.
+				continue
+			}
+			keys := pathIdentKeys(pe, metadata)
+			found := false
+			for _, v := range a {
+				if o, ok := v.(jsonObject); ok && o.hasPathIdent(pe, keys) {
+					n, found = o, true
+					break
+				}
+			}
+			if !found {
+				return nil, false
+			}
+		case voidNode:
+			// Trailing metadata.
+		default:
+			return nil, false
+		}
+	}
+	return n, true
+}
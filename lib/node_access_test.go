@@ -0,0 +1,144 @@
+package jd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	cases := []struct {
+		json string
+		want Kind
+	}{
+		{``, Void},
+		{`null`, Null},
+		{`true`, Bool},
+		{`1.5`, Number},
+		{`"a"`, String},
+		{`{}`, Object},
+		{`[]`, Array},
+	}
+	for _, c := range cases {
+		n, err := ReadJsonString(c.json)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if got := KindOf(n); got != c.want {
+			t.Errorf("KindOf(%v) = %v. Want %v.", c.json, got, c.want)
+		}
+		if got := KindOf(dispatch(n, []Metadata{SET})); got != c.want {
+			t.Errorf("KindOf(%v) as set = %v. Want %v.", c.json, got, c.want)
+		}
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	n, err := ReadJsonString(`{"b":[1,"x",true,null],"a":{}}`)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got, want := Keys(n), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys = %v. Want %v.", got, want)
+	}
+	if Keys(jsonString("a")) != nil {
+		t.Errorf("Want nil keys for a string.")
+	}
+	if Len(n) != 2 {
+		t.Errorf("Len(object) = %v. Want 2.", Len(n))
+	}
+	b, ok := Field(n, "b")
+	if !ok {
+		t.Fatalf("Want field b.")
+	}
+	if Len(b) != 4 {
+		t.Errorf("Len(array) = %v. Want 4.", Len(b))
+	}
+	if _, ok := Field(n, "c"); ok {
+		t.Errorf("Want no field c.")
+	}
+	if _, ok := Index(b, 4); ok {
+		t.Errorf("Want no index 4.")
+	}
+	e, _ := Index(b, 0)
+	if f, ok := NumberValue(e); !ok || f != 1 {
+		t.Errorf("NumberValue = %v, %v. Want 1, true.", f, ok)
+	}
+	e, _ = Index(b, 1)
+	if s, ok := StringValue(e); !ok || s != "x" {
+		t.Errorf("StringValue = %v, %v. Want x, true.", s, ok)
+	}
+	e, _ = Index(b, 2)
+	if v, ok := BoolValue(e); !ok || !v {
+		t.Errorf("BoolValue = %v, %v. Want true, true.", v, ok)
+	}
+	if _, ok := StringValue(e); ok {
+		t.Errorf("Want StringValue of a bool to fail.")
+	}
+	e, _ = Index(b, 3)
+	if KindOf(e) != Null {
+		t.Errorf("Want null. Got %v.", KindOf(e))
+	}
+}
+
+func TestGet(t *testing.T) {
+	doc := `{"a":[{"id":"x","v":1},{"id":"y","v":2}],"b":{"c":"{\"d\":[3]}"},"e":[5,6]}`
+	cases := []struct {
+		path string
+		want string
+	}{
+		{`[]`, doc},
+		{`["a",1,"v"]`, `2`},
+		{`["a",{"id":"y"},"v"]`, `2`},
+		{`["a",["set","setkeys=id"],{"id":"x"},"v"]`, `1`},
+		{`["a",["set"],{}]`, `[{"id":"x","v":1},{"id":"y","v":2}]`},
+		{`["b","c",["json"],"d",0]`, `3`},
+		{`["e",["multiset"]]`, `[5,6]`},
+		{`["e",-1]`, ``},
+		{`["a",{"id":"z"}]`, ``},
+		{`["b","c","d"]`, ``},
+		{`["b","c",["yaml"],"d"]`, ``},
+		{`["e","x"]`, ``},
+	}
+	n, err := ReadJsonString(doc)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	for _, c := range cases {
+		p, err := ParsePath(c.path)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		got, ok := Get(n, p)
+		if c.want == `` {
+			if ok {
+				t.Errorf("Get(%v) = %v. Want not found.", c.path, got.Json())
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("Get(%v) not found. Want %v.", c.path, c.want)
+			continue
+		}
+		want, _ := ReadJsonString(c.want)
+		if !got.Equals(want) {
+			t.Errorf("Get(%v) = %v. Want %v.", c.path, got.Json(), c.want)
+		}
+	}
+}
+
+func TestGetDiffPath(t *testing.T) {
+	a, _ := ReadJsonString(`{"a":[{"id":"x","v":1}]}`)
+	b, _ := ReadJsonString(`{"a":[{"id":"x","v":2}]}`)
+	metadata := []Metadata{Setkeys("id")}
+	d := a.Diff(b, metadata...)
+	if len(d) != 1 {
+		t.Fatalf("Want 1 diff element. Got %v.", d.Render())
+	}
+	got, ok := Get(b, d[0].Path)
+	if !ok {
+		t.Fatalf("Path %v not found.", d.Render())
+	}
+	if f, _ := NumberValue(got); f != 2 {
+		t.Errorf("Get = %v. Want 2.", got.Json())
+	}
+}
@@ -0,0 +1,50 @@
+package jd
+
+// NewNull returns a JSON null.
+func NewNull() JsonNode {
+	return jsonNull{}
+}
+
+// NewBool returns a JSON boolean.
+func NewBool(b bool) JsonNode {
+	return jsonBool(b)
+}
+
+// NewNumber returns a JSON number.
+func NewNumber(f float64) JsonNode {
+	return jsonNumber(f)
+}
+
+// NewString returns a JSON string.
+func NewString(s string) JsonNode {
+	return jsonString(s)
+}
+
+// NewArray returns a JSON array of the given elements. Nil elements are
+// JSON null.
+func NewArray(elements ...JsonNode) JsonNode {
+	a := make(jsonArray, len(elements))
+	for i, e := range elements {
+		if e == nil {
+			e = jsonNull{}
+		}
+		a[i] = e
+	}
+	return a
+}
+
+// NewObject returns a JSON object with the given properties. Nil values
+// are JSON null.
+func NewObject(properties map[string]JsonNode) JsonNode {
+	o := jsonObject{
+		properties: make(map[string]JsonNode, len(properties)),
+		idKeys:     make(map[string]bool),
+	}
+	for k, v := range properties {
+		if v == nil {
+			v = jsonNull{}
+		}
+		o.properties[k] = v
+	}
+	return o
+}
@@ -0,0 +1,27 @@
+package jd
+
+import (
+	"testing"
+)
+
+func TestBuilders(t *testing.T) {
+	n := NewObject(map[string]JsonNode{
+		"a": NewArray(NewNumber(1), NewString("x"), NewBool(false), nil),
+		"b": NewNull(),
+		"c": nil,
+		"d": NewObject(nil),
+	})
+	want, err := ReadJsonString(`{"a":[1,"x",false,null],"b":null,"c":null,"d":{}}`)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !n.Equals(want) {
+		t.Errorf("Got %v. Want %v.", n.Json(), want.Json())
+	}
+	if d := n.Diff(want); len(d) != 0 {
+		t.Errorf("Want no diff. Got %v.", d.Render())
+	}
+	if got, err := n.Patch(nil); err != nil || !got.Equals(want) {
+		t.Errorf("Patch with empty diff = %v, %v.", got, err)
+	}
+}
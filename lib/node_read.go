@@ -2,18 +2,22 @@ package jd
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
 func ReadJsonFile(filename string) (JsonNode, error) {
-	bytes, err := ioutil.ReadFile(filename)
+	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
-	return unmarshal(bytes, json.Unmarshal)
+	defer f.Close()
+	return ReadJson(f)
 }
 
 func ReadYamlFile(filename string) (JsonNode, error) {
@@ -25,13 +29,126 @@ func ReadYamlFile(filename string) (JsonNode, error) {
 }
 
 func ReadJsonString(s string) (JsonNode, error) {
-	return unmarshal([]byte(s), json.Unmarshal)
+	return ReadJson(strings.NewReader(s))
 }
 
 func ReadYamlString(s string) (JsonNode, error) {
 	return unmarshal([]byte(s), yaml.Unmarshal)
 }
 
+// ReadJson reads a single JSON document from r. Nodes are built directly
+// from the token stream without holding the document text in memory.
+// Empty input is a void node.
+func ReadJson(r io.Reader) (JsonNode, error) {
+	dec := json.NewDecoder(r)
+	t, err := dec.Token()
+	if err == io.EOF {
+		return voidNode{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	n, err := readJsonToken(dec, t)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("Invalid JSON. Unexpected data after the top-level value.")
+	}
+	return n, nil
+}
+
+// ReadYaml reads a single YAML document from r. Empty input is a void
+// node.
+func ReadYaml(r io.Reader) (JsonNode, error) {
+	var v interface{}
+	err := yaml.NewDecoder(r).Decode(&v)
+	if err == io.EOF {
+		return voidNode{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newYamlNode(v)
+}
+
+func readJsonToken(dec *json.Decoder, t json.Token) (JsonNode, error) {
+	switch t := t.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			o := jsonObject{
+				properties: make(map[string]JsonNode),
+				idKeys:     make(map[string]bool),
+			}
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("Invalid JSON. Expected object key. Got %v.", k)
+				}
+				v, err := readJson(dec)
+				if err != nil {
+					return nil, err
+				}
+				o.properties[key] = v
+			}
+			if err := readJsonDelim(dec); err != nil {
+				return nil, err
+			}
+			return o, nil
+		case '[':
+			a := make(jsonArray, 0)
+			for dec.More() {
+				v, err := readJson(dec)
+				if err != nil {
+					return nil, err
+				}
+				a = append(a, v)
+			}
+			if err := readJsonDelim(dec); err != nil {
+				return nil, err
+			}
+			return a, nil
+		}
+		return nil, fmt.Errorf("Invalid JSON. Unexpected %v.", t)
+	case string:
+		return jsonString(t), nil
+	case float64:
+		return jsonNumber(t), nil
+	case bool:
+		return jsonBool(t), nil
+	case nil:
+		return jsonNull(nil), nil
+	}
+	return nil, fmt.Errorf("Unsupported type %T.", t)
+}
+
+func readJson(dec *json.Decoder) (JsonNode, error) {
+	t, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return readJsonToken(dec, t)
+}
+
+func readJsonDelim(dec *json.Decoder) error {
+	_, err := dec.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
 func unmarshal(bytes []byte, fn func([]byte, interface{}) error) (JsonNode, error) {
 	if strings.TrimSpace(string(bytes)) == "" {
 		return voidNode{}, nil
@@ -41,9 +158,41 @@ func unmarshal(bytes []byte, fn func([]byte, interface{}) error) (JsonNode, erro
 	if err != nil {
 		return nil, err
 	}
-	n, err := NewJsonNode(v)
+	n, err := newYamlNode(v)
 	if err != nil {
 		return nil, err
 	}
 	return n, nil
 }
+
+// newYamlNode converts a decoded YAML value. YAML 1.1 decodes keys such
+// as `on`, `yes` and `1.0` as bools and numbers. Their text is lost so
+// only string keys are accepted rather than inventing keys which were not
+// in the input.
+func newYamlNode(v interface{}) (JsonNode, error) {
+	if err := checkYamlKeys(v); err != nil {
+		return nil, err
+	}
+	return NewJsonNode(v)
+}
+
+func checkYamlKeys(v interface{}) error {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		for k, e := range t {
+			if _, ok := k.(string); !ok {
+				return fmt.Errorf("Unsupported key type %T", k)
+			}
+			if err := checkYamlKeys(e); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, e := range t {
+			if err := checkYamlKeys(e); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package jd
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -13,6 +15,64 @@ func TestUnmarshal(t *testing.T) {
 	checkUnmarshal(t, `1`, jsonNumber(1.0))
 	checkUnmarshal(t, `{}`, jsonObject{})
 	checkUnmarshal(t, `[]`, jsonArray{})
+	checkUnmarshal(t, " \n", voidNode{})
+}
+
+func TestReadJson(t *testing.T) {
+	cases := []struct {
+		json    string
+		wantErr bool
+	}{
+		{`{"a":[1,"b",true,null,{"c":{}}],"d":-1.5e3}`, false},
+		{`{"a":1,"a":2}`, false},
+		{`[[[[]]]]`, false},
+		{`{"a":1`, true},
+		{`[1,2`, true},
+		{`[1 2]`, true},
+		{`{1:2}`, true},
+		{`}`, true},
+		{`1 2`, true},
+		{`{} x`, true},
+	}
+	for _, c := range cases {
+		got, err := ReadJson(strings.NewReader(c.json))
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ReadJson(%v) wanted error. Got %v.", c.json, got.Json())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ReadJson(%v) unexpected error: %v", c.json, err)
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(c.json), &v); err != nil {
+			t.Fatalf(err.Error())
+		}
+		want, err := NewJsonNode(v)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !got.Equals(want) {
+			t.Errorf("ReadJson(%v) = %v. Want %v.", c.json, got.Json(), want.Json())
+		}
+	}
+}
+
+func TestReadYaml(t *testing.T) {
+	got, err := ReadYaml(strings.NewReader("a:\n- 1\n- b\n"))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	want, _ := ReadJsonString(`{"a":[1,"b"]}`)
+	if !got.Equals(want) {
+		t.Errorf("Got %v. Want %v.", got.Json(), want.Json())
+	}
+	got, err = ReadYaml(strings.NewReader(""))
+	if err != nil || got != (voidNode{}) {
+		t.Errorf("Got %v, %v. Want void.", got, err)
+	}
 }
 
 func checkUnmarshal(t *testing.T, s string, n JsonNode) {
@@ -101,6 +161,12 @@ func TestReadDiffError(t *testing.T) {
 	checkReadDiffError(t,
 		`@ `,
 		`- 1`)
+	checkReadDiffError(t,
+		`@ []`,
+		`+`)
+	checkReadDiffError(t,
+		`@ ["a"]`,
+		`- `)
 }
 
 func checkReadDiff(t *testing.T, d Diff, diffLines ...string) {
@@ -132,8 +198,8 @@ func checkReadDiffError(t *testing.T, diffLines ...string) {
 	}
 }
 
-func p(elements ...interface{}) path {
-	var path path
+func p(elements ...interface{}) Path {
+	var path Path
 	for _, e := range elements {
 		n, err := NewJsonNode(e)
 		if err != nil {
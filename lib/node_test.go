@@ -0,0 +1,110 @@
+package jd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNewJsonNode(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{{
+		name:  "int64",
+		value: int64(1),
+		want:  `1`,
+	}, {
+		name:  "uint",
+		value: uint(2),
+		want:  `2`,
+	}, {
+		name:  "float32",
+		value: float32(0.5),
+		want:  `0.5`,
+	}, {
+		name:  "json number",
+		value: json.Number("1.25"),
+		want:  `1.25`,
+	}, {
+		name:  "string slice",
+		value: []string{"a", "b"},
+		want:  `["a","b"]`,
+	}, {
+		name:  "string map",
+		value: map[string]string{"a": "b"},
+		want:  `{"a":"b"}`,
+	}, {
+		name:  "typed nested values",
+		value: map[string]interface{}{"a": []int64{1}, "b": map[string]uint8{"c": 3}},
+		want:  `{"a":[1],"b":{"c":3}}`,
+	}, {
+		name:  "interface map with json node values",
+		value: map[interface{}]interface{}{"a": jsonNumber(1), "b": 2},
+		want:  `{"a":1,"b":2}`,
+	}, {
+		name:  "interface map with scalar keys",
+		value: map[interface{}]interface{}{1: "a", uint8(2): "b"},
+		want:  `{"1":"a","2":"b"}`,
+	}, {
+		name:  "list with json node values",
+		value: []interface{}{jsonString("a"), 1},
+		want:  `["a",1]`,
+	}, {
+		name:  "json node",
+		value: jsonBool(true),
+		want:  `true`,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n, err := NewJsonNode(c.value)
+			if err != nil {
+				t.Fatalf("Want no error. Got %v.", err)
+			}
+			if got := n.Json(); got != c.want {
+				t.Errorf("NewJsonNode(%#v) = %v. Want %v.", c.value, got, c.want)
+			}
+		})
+	}
+}
+
+func TestNewJsonNodeError(t *testing.T) {
+	for _, v := range []interface{}{
+		make(chan int),
+		map[interface{}]interface{}{[2]int{1, 2}: "a"},
+		map[interface{}]interface{}{true: "a"},
+		map[interface{}]interface{}{1.5: "a"},
+		json.Number("abc"),
+	} {
+		if _, err := NewJsonNode(v); err == nil {
+			t.Errorf("NewJsonNode(%#v) wanted error. Got nil.", v)
+		}
+	}
+}
+
+func TestReadYamlNonStringKeys(t *testing.T) {
+	for _, y := range []string{
+		"on: push\n",
+		"yes: a\n",
+		"1: a\n",
+		"1.5: a\n",
+		"a:\n- y: 1\n",
+	} {
+		if n, err := ReadYamlString(y); err == nil {
+			t.Errorf("ReadYamlString(%q) wanted error. Got %v.", y, n.Json())
+		}
+		if n, err := ReadYaml(strings.NewReader(y)); err == nil {
+			t.Errorf("ReadYaml(%q) wanted error. Got %v.", y, n.Json())
+		}
+	}
+	n, err := ReadYamlString("\"on\": push\n")
+	if err != nil {
+		t.Fatalf("Want no error. Got %v.", err)
+	}
+	want := `{"on":"push"}`
+	if got := n.Json(); got != want {
+		t.Errorf("Got %v. Want %v.", got, want)
+	}
+}
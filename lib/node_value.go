@@ -0,0 +1,399 @@
+package jd
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonNodeType        = reflect.TypeOf((*JsonNode)(nil)).Elem()
+	numberType          = reflect.TypeOf(json.Number(""))
+)
+
+// FromValue converts an arbitrary Go value into a JsonNode the same way
+// encoding/json would marshal it, without the round trip through JSON
+// text. Structs honor `json` field tags including "-", "omitempty" and
+// "string". Types implementing json.Marshaler or encoding.TextMarshaler
+// are converted using their own encoding.
+func FromValue(v interface{}) (JsonNode, error) {
+	return fromValue(reflect.ValueOf(v))
+}
+
+func fromValue(v reflect.Value) (JsonNode, error) {
+	r := valueReader{
+		visiting: make(map[visit]bool),
+	}
+	return r.read(v)
+}
+
+// valueReader converts Go values. It tracks the pointers, maps and
+// slices being converted to report cycles instead of recursing forever,
+// as encoding/json does.
+type valueReader struct {
+	visiting map[visit]bool
+}
+
+// visit identifies a pointer, map or slice value. Slices sharing an
+// array with different lengths are different values.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// enter marks v as being converted. It returns an error if v is already
+// being converted further up.
+func (r *valueReader) enter(v reflect.Value) (visit, error) {
+	k := visit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		k.len = v.Len()
+	}
+	if r.visiting[k] {
+		return k, fmt.Errorf("Unsupported value. Encountered a cycle via %v.", v.Type())
+	}
+	r.visiting[k] = true
+	return k, nil
+}
+
+func (r *valueReader) read(v reflect.Value) (JsonNode, error) {
+	if !v.IsValid() {
+		return jsonNull{}, nil
+	}
+	if v.Type().Implements(jsonNodeType) && !isNilValue(v) {
+		return v.Interface().(JsonNode), nil
+	}
+	if v.Type() == numberType {
+		f, err := json.Number(v.String()).Float64()
+		if err != nil {
+			return nil, err
+		}
+		return jsonNumber(f), nil
+	}
+	if n, ok, err := fromMarshaler(v); ok {
+		return n, err
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return jsonNull{}, nil
+		}
+		if v.Kind() == reflect.Interface {
+			return r.read(v.Elem())
+		}
+		k, err := r.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer delete(r.visiting, k)
+		return r.read(v.Elem())
+	case reflect.Bool:
+		return jsonBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return jsonNumber(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return jsonNumber(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return jsonNumber(v.Float()), nil
+	case reflect.String:
+		return jsonString(v.String()), nil
+	case reflect.Slice:
+		if v.IsNil() {
+			return jsonNull{}, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// Byte slices are base64 encoded like encoding/json.
+			return jsonString(base64.StdEncoding.EncodeToString(v.Bytes())), nil
+		}
+		k, err := r.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer delete(r.visiting, k)
+		return r.readList(v)
+	case reflect.Array:
+		return r.readList(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return jsonNull{}, nil
+		}
+		k, err := r.enter(v)
+		if err != nil {
+			return nil, err
+		}
+		defer delete(r.visiting, k)
+		return r.readMap(v)
+	case reflect.Struct:
+		return r.readStruct(v)
+	default:
+		return nil, fmt.Errorf("Unsupported type %v", v.Type())
+	}
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+
+func fromMarshaler(v reflect.Value) (JsonNode, bool, error) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false, nil
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(jsonMarshalerType) {
+		v = v.Addr()
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		b, err := v.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil, true, err
+		}
+		n, err := ReadJsonString(string(b))
+		return n, true, err
+	}
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, true, err
+		}
+		return jsonString(b), true, nil
+	}
+	return nil, false, nil
+}
+
+func (r *valueReader) readList(v reflect.Value) (JsonNode, error) {
+	l := make(jsonArray, v.Len())
+	for i := range l {
+		n, err := r.read(v.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		l[i] = n
+	}
+	return l, nil
+}
+
+func (r *valueReader) readMap(v reflect.Value) (JsonNode, error) {
+	o := jsonObject{
+		properties: make(map[string]JsonNode),
+		idKeys:     make(map[string]bool),
+	}
+	iter := v.MapRange()
+	for iter.Next() {
+		k, err := mapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		n, err := r.read(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		o.properties[k] = n
+	}
+	return o, nil
+}
+
+// mapKey converts a map key to an object key. Like encoding/json it
+// accepts strings, integers and text marshalers.
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.Interface {
+		if k.IsNil() {
+			return "", fmt.Errorf("Unsupported key type %v", k.Type())
+		}
+		k = k.Elem()
+	}
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("Unsupported key type %v", k.Type())
+}
+
+func (r *valueReader) readStruct(v reflect.Value) (JsonNode, error) {
+	o := jsonObject{
+		properties: make(map[string]JsonNode),
+		idKeys:     make(map[string]bool),
+	}
+	for _, f := range jsonFields(v.Type()) {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			// Nil embedded pointer.
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		n, err := r.read(fv)
+		if err != nil {
+			return nil, err
+		}
+		if f.quoted {
+			switch n.(type) {
+			case jsonString, jsonNumber, jsonBool:
+				n = jsonString(n.Json())
+			}
+		}
+		o.properties[f.name] = n
+	}
+	return o, nil
+}
+
+// jsonField is a struct field visible to encoding/json.
+type jsonField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	quoted    bool
+	tagged    bool
+}
+
+// jsonFields lists the fields of a struct type visible to encoding/json,
+// including fields promoted from embedded structs. As in encoding/json,
+// of the fields sharing a name the shallowest wins, then a tagged one.
+// Names still ambiguous are dropped.
+func jsonFields(t reflect.Type) []jsonField {
+	byName := make(map[string][]jsonField)
+	visited := make(map[reflect.Type]bool)
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		if visited[t] {
+			// Embedded in itself. The shallower fields win anyway.
+			return
+		}
+		visited[t] = true
+		defer delete(visited, t)
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts := tag, ""
+			if i := strings.Index(tag, ","); i != -1 {
+				name, opts = tag[:i], tag[i+1:]
+			}
+			fieldIndex := append(append([]int{}, index...), i)
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				walk(ft, fieldIndex)
+				continue
+			}
+			if sf.PkgPath != "" {
+				// Unexported.
+				continue
+			}
+			f := jsonField{
+				name:   name,
+				index:  fieldIndex,
+				tagged: name != "",
+			}
+			if f.name == "" {
+				f.name = sf.Name
+			}
+			for _, o := range strings.Split(opts, ",") {
+				switch o {
+				case "omitempty":
+					f.omitEmpty = true
+				case "string":
+					f.quoted = true
+				}
+			}
+			byName[f.name] = append(byName[f.name], f)
+		}
+	}
+	walk(t, nil)
+	fields := make([]jsonField, 0, len(byName))
+	for _, candidates := range byName {
+		if f, ok := dominantField(candidates); ok {
+			fields = append(fields, f)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	return fields
+}
+
+// dominantField picks the field encoding/json uses among fields with
+// the same name: the only shallowest one, or the only tagged one of
+// those. Otherwise the name is ambiguous.
+func dominantField(fields []jsonField) (jsonField, bool) {
+	depth := len(fields[0].index)
+	for _, f := range fields {
+		if len(f.index) < depth {
+			depth = len(f.index)
+		}
+	}
+	var shallowest []jsonField
+	for _, f := range fields {
+		if len(f.index) == depth {
+			shallowest = append(shallowest, f)
+		}
+	}
+	if len(shallowest) == 1 {
+		return shallowest[0], true
+	}
+	var tagged []jsonField
+	for _, f := range shallowest {
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	return jsonField{}, false
+}
+
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package jd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type valueInner struct {
+	Name string `json:"name"`
+}
+
+type valueEmbedded struct {
+	Embedded string
+	Shadowed string
+}
+
+type valueOuter struct {
+	valueEmbedded
+	Shadowed  string            `json:"shadowed"`
+	Plain     string            ``
+	Renamed   int               `json:"renamed"`
+	Omitted   string            `json:"omitted,omitempty"`
+	Skipped   string            `json:"-"`
+	Dash      string            `json:"-,"`
+	Quoted    int64             `json:"quoted,string"`
+	Ptr       *valueInner       `json:"ptr"`
+	NilPtr    *valueInner       `json:"nilPtr"`
+	Slice     []uint8           `json:"bytes"`
+	Strings   []string          `json:"strings"`
+	NilSlice  []string          `json:"nilSlice"`
+	Map       map[string]string `json:"map"`
+	IntMap    map[int]bool      `json:"intMap"`
+	Time      time.Time         `json:"time"`
+	Any       interface{}       `json:"any"`
+	Array     [2]float32        `json:"array"`
+	unexposed string
+}
+
+func TestFromValue(t *testing.T) {
+	v := valueOuter{
+		valueEmbedded: valueEmbedded{
+			Embedded: "e",
+			Shadowed: "inner",
+		},
+		Shadowed: "outer",
+		Plain:    "p",
+		Renamed:  1,
+		Skipped:  "s",
+		Dash:     "d",
+		Quoted:   42,
+		Ptr:      &valueInner{Name: "n"},
+		Slice:    []byte("hi"),
+		Strings:  []string{"a", "b"},
+		Map:      map[string]string{"k": "v"},
+		IntMap:   map[int]bool{1: true},
+		Time:     time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		Any:      map[string]interface{}{"x": []interface{}{1.0}},
+		Array:    [2]float32{1, 2},
+	}
+	got, err := FromValue(v)
+	if err != nil {
+		t.Fatalf("Want no error. Got %v.", err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	want, err := ReadJsonString(string(b))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !want.Equals(got) {
+		t.Errorf("FromValue(%v) = %v. Want %v.", v, got.Json(), want.Json())
+	}
+}
+
+func TestFromValueScalars(t *testing.T) {
+	cases := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, `null`},
+		{true, `true`},
+		{int8(-3), `-3`},
+		{uint64(7), `7`},
+		{float32(1.5), `1.5`},
+		{"a", `"a"`},
+		{(*valueInner)(nil), `null`},
+		{[]int{1, 2}, `[1,2]`},
+		{map[string]int{}, `{}`},
+		{jsonNumber(3), `3`},
+	}
+	for _, c := range cases {
+		got, err := FromValue(c.value)
+		if err != nil {
+			t.Errorf("FromValue(%#v) error: %v.", c.value, err)
+			continue
+		}
+		if got.Json() != c.want {
+			t.Errorf("FromValue(%#v) = %v. Want %v.", c.value, got.Json(), c.want)
+		}
+	}
+}
+
+func TestFromValueError(t *testing.T) {
+	for _, v := range []interface{}{
+		make(chan int),
+		func() {},
+		map[[2]int]string{{1, 2}: "a"},
+		[]interface{}{complex(1, 2)},
+	} {
+		if _, err := FromValue(v); err == nil {
+			t.Errorf("FromValue(%T) wanted error. Got nil.", v)
+		}
+	}
+}
+
+type valueNode struct {
+	Name string     `json:"name"`
+	Next *valueNode `json:"next"`
+}
+
+func TestFromValueCycle(t *testing.T) {
+	n := &valueNode{Name: "a"}
+	n.Next = n
+	if _, err := FromValue(n); err == nil {
+		t.Errorf("FromValue of a cycle wanted error. Got nil.")
+	}
+	m := map[string]interface{}{}
+	m["self"] = m
+	if _, err := FromValue(m); err == nil {
+		t.Errorf("FromValue of a map cycle wanted error. Got nil.")
+	}
+	// Shared values which aren't cycles are fine.
+	shared := &valueNode{Name: "b"}
+	got, err := FromValue([]*valueNode{shared, shared})
+	if err != nil {
+		t.Fatalf("Want no error. Got %v.", err)
+	}
+	want := `[{"name":"b","next":null},{"name":"b","next":null}]`
+	if got.Json() != want {
+		t.Errorf("Got %v. Want %v.", got.Json(), want)
+	}
+}
+
+type valueAmbiguousA struct {
+	Name string
+	A    string
+}
+
+type valueAmbiguousB struct {
+	Name string
+	B    string
+}
+
+type valueTaggedB struct {
+	Name string `json:"Name"`
+	B    string
+}
+
+type valueAmbiguous struct {
+	valueAmbiguousA
+	valueAmbiguousB
+}
+
+type valueTagged struct {
+	valueAmbiguousA
+	valueTaggedB
+}
+
+type valueSelf struct {
+	*valueSelf
+	A string
+}
+
+func TestFromValueEmbeddedNames(t *testing.T) {
+	for _, v := range []interface{}{
+		valueAmbiguous{
+			valueAmbiguousA{Name: "a", A: "a"},
+			valueAmbiguousB{Name: "b", B: "b"},
+		},
+		valueTagged{
+			valueAmbiguousA{Name: "a", A: "a"},
+			valueTaggedB{Name: "b", B: "b"},
+		},
+		valueSelf{A: "a"},
+	} {
+		got, err := FromValue(v)
+		if err != nil {
+			t.Fatalf("Want no error. Got %v.", err)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		want, err := ReadJsonString(string(b))
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !want.Equals(got) {
+			t.Errorf("FromValue(%#v) = %v. Want %v.", v, got.Json(), want.Json())
+		}
+	}
+}
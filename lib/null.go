@@ -30,10 +30,10 @@ func (n jsonNull) hashCode(metadata []Metadata) [8]byte {
 }
 
 func (n jsonNull) Diff(node JsonNode, metadata ...Metadata) Diff {
-	return n.diff(node, make(path, 0), metadata)
+	return n.diff(node, make(Path, 0), metadata)
 }
 
-func (n jsonNull) diff(node JsonNode, path path, metadata []Metadata) Diff {
+func (n jsonNull) diff(node JsonNode, path Path, metadata []Metadata) Diff {
 	d := make(Diff, 0)
 	if n.Equals(node) {
 		return d
@@ -50,7 +50,7 @@ func (n jsonNull) Patch(d Diff) (JsonNode, error) {
 	return patchAll(n, d)
 }
 
-func (n jsonNull) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (n jsonNull) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
 	if len(pathAhead) != 0 {
 		return patchErrExpectColl(n, pathAhead[0])
 	}
@@ -40,10 +40,10 @@ func (n jsonNumber) hashCode(metadata []Metadata) [8]byte {
 }
 
 func (n jsonNumber) Diff(node JsonNode, metadata ...Metadata) Diff {
-	return n.diff(node, make(path, 0), metadata)
+	return n.diff(node, make(Path, 0), metadata)
 }
 
-func (n jsonNumber) diff(node JsonNode, path path, metadata []Metadata) Diff {
+func (n jsonNumber) diff(node JsonNode, path Path, metadata []Metadata) Diff {
 	d := make(Diff, 0)
 	if n.Equals(node) {
 		return d
@@ -60,7 +60,7 @@ func (n jsonNumber) Patch(d Diff) (JsonNode, error) {
 	return patchAll(n, d)
 }
 
-func (n jsonNumber) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (n jsonNumber) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
 	if len(pathAhead) != 0 {
 		return patchErrExpectColl(n, pathAhead[0])
 	}
@@ -47,7 +47,7 @@ func (o1 jsonObject) Equals(n JsonNode, metadata ...Metadata) bool {
 		if !ok {
 			return false
 		}
-		ret := val1.Equals(val2)
+		ret := val1.Equals(val2, metadata...)
 		if !ret {
 			return false
 		}
@@ -56,55 +56,90 @@ func (o1 jsonObject) Equals(n JsonNode, metadata ...Metadata) bool {
 }
 
 func (o jsonObject) hashCode(metadata []Metadata) [8]byte {
-	keys := make([]string, 0, len(o.properties))
-	for k := range o.properties {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	a := make([]byte, 0, len(o.properties)*16)
-	for _, k := range keys {
-		keyHash := hash([]byte(k))
-		a = append(a, keyHash[:]...)
-		valueHash := o.properties[k].hashCode(metadata)
-		a = append(a, valueHash[:]...)
-	}
-	return hash(a)
+	return memoHash(o, o.properties, memoObject, metadata, func() [8]byte {
+		keys := make([]string, 0, len(o.properties))
+		for k := range o.properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		a := make([]byte, 0, len(o.properties)*16)
+		for _, k := range keys {
+			keyHash := hash([]byte(k))
+			a = append(a, keyHash[:]...)
+			valueHash := o.properties[k].hashCode(metadata)
+			a = append(a, valueHash[:]...)
+		}
+		return hash(a)
+	})
 }
 
 // ident is the identity of the json object based on either the hash of a
 // given set of keys or the full object if no keys are present.
 func (o jsonObject) ident(metadata []Metadata) [8]byte {
+	return memoHash(o, o.properties, memoIdent, metadata, func() [8]byte {
+		keys := getSetkeysMetadata(metadata).mergeKeys(o.idKeys)
+		if len(keys) == 0 {
+			return o.hashCode(metadata)
+		}
+		hashes := make(hashCodes, 0)
+		for key := range keys {
+			v, ok := o.properties[key]
+			if ok {
+				hashes = append(hashes, v.hashCode(metadata))
+			}
+		}
+		if len(hashes) == 0 {
+			return o.hashCode(metadata)
+		}
+		return hashes.combine()
+	})
+}
+
+// identObject is the portion of the json object which identifies it within a
+// set. It is written into diff paths so the object can be found again when
+// patching, regardless of changes to its other properties.
+func (o jsonObject) identObject(metadata []Metadata) jsonObject {
 	keys := getSetkeysMetadata(metadata).mergeKeys(o.idKeys)
 	if len(keys) == 0 {
-		return o.hashCode(metadata)
+		return o
+	}
+	id := jsonObject{
+		properties: make(map[string]JsonNode),
+		idKeys:     make(map[string]bool),
 	}
-	hashes := make(hashCodes, 0)
 	for key := range keys {
-		v, ok := o.properties[key]
-		if ok {
-			hashes = append(hashes, v.hashCode(metadata))
+		if v, ok := o.properties[key]; ok {
+			id.properties[key] = v
 		}
 	}
-	if len(hashes) == 0 {
-		return o.hashCode(metadata)
+	if len(id.properties) == 0 {
+		return o
 	}
-	return hashes.combine()
+	return id
 }
 
-func (o jsonObject) pathIdent(pathObject jsonObject, metadata []Metadata) [8]byte {
+// pathIdentKeys are the keys of a diff path object and any set keys. They
+// identify the object the path refers to within a set.
+func pathIdentKeys(pathObject jsonObject, metadata []Metadata) map[string]bool {
 	idKeys := map[string]bool{}
 	for k := range pathObject.properties {
 		idKeys[k] = true
 	}
-	keys := getSetkeysMetadata(metadata).mergeKeys(idKeys)
-	id := make(map[string]interface{})
+	return getSetkeysMetadata(metadata).mergeKeys(idKeys)
+}
+
+// hasPathIdent reports whether the json object has the same values as the
+// diff path object for the given path ident keys. It is called for every
+// element of a set while patching, so it compares in place.
+func (o jsonObject) hasPathIdent(pathObject jsonObject, keys map[string]bool) bool {
 	for key := range keys {
-		if value, ok := o.properties[key]; ok {
-			id[key] = value
+		v1, ok1 := o.properties[key]
+		v2, ok2 := pathObject.properties[key]
+		if ok1 != ok2 || ok1 && !v1.Equals(v2) {
+			return false
 		}
 	}
-	e, _ := NewJsonNode(id)
-	return e.hashCode([]Metadata{})
+	return true
 }
 
 func (k1 *setkeysMetadata) mergeKeys(k2 map[string]bool) map[string]bool {
@@ -123,11 +158,17 @@ func (k1 *setkeysMetadata) mergeKeys(k2 map[string]bool) map[string]bool {
 }
 
 func (o jsonObject) Diff(n JsonNode, metadata ...Metadata) Diff {
-	return o.diff(n, make(path, 0), metadata)
+	metadata = withHashMemo(metadata)
+	return o.diff(n, make(Path, 0), metadata)
 }
 
-func (o1 jsonObject) diff(n JsonNode, path path, metadata []Metadata) Diff {
-	d := make(Diff, 0)
+func (o1 jsonObject) diff(n JsonNode, path Path, metadata []Metadata) Diff {
+	return collectDiff(func(emit emitFunc) error {
+		return o1.diffTo(n, path, metadata, emit)
+	})
+}
+
+func (o1 jsonObject) diffTo(n JsonNode, path Path, metadata []Metadata, emit emitFunc) error {
 	o2, ok := n.(jsonObject)
 	if !ok {
 		// Different types
@@ -136,7 +177,7 @@ func (o1 jsonObject) diff(n JsonNode, path path, metadata []Metadata) Diff {
 			OldValues: []JsonNode{o1},
 			NewValues: []JsonNode{n},
 		}
-		return append(d, e)
+		return emit(e)
 	}
 	o1Keys := make([]string, 0, len(o1.properties))
 	for k := range o1.properties {
@@ -148,12 +189,30 @@ func (o1 jsonObject) diff(n JsonNode, path path, metadata []Metadata) Diff {
 		o2Keys = append(o2Keys, k)
 	}
 	sort.Strings(o2Keys)
-	for _, k1 := range o1Keys {
+	var subDiffs []Diff
+	if parallel(metadata, len(o1Keys)) {
+		subDiffs = make([]Diff, len(o1Keys))
+		forEachParallel(len(o1Keys), func(i int) {
+			k1 := o1Keys[i]
+			if v2, ok := o2.properties[k1]; ok {
+				p := append(path.clone(), jsonString(k1))
+				subDiffs[i] = o1.properties[k1].diff(v2, p, metadata)
+			}
+		})
+	}
+	for i, k1 := range o1Keys {
 		v1 := o1.properties[k1]
 		if v2, ok := o2.properties[k1]; ok {
 			// Both keys are present
-			subDiff := v1.diff(v2, append(path, jsonString(k1)), metadata)
-			d = append(d, subDiff...)
+			var err error
+			if subDiffs != nil {
+				err = emitAll(subDiffs[i], emit)
+			} else {
+				err = diffTo(v1, v2, append(path, jsonString(k1)), metadata, emit)
+			}
+			if err != nil {
+				return err
+			}
 		} else {
 			// O2 missing key
 			e := DiffElement{
@@ -161,7 +220,9 @@ func (o1 jsonObject) diff(n JsonNode, path path, metadata []Metadata) Diff {
 				OldValues: nodeList(v1),
 				NewValues: nodeList(),
 			}
-			d = append(d, e)
+			if err := emit(e); err != nil {
+				return err
+			}
 		}
 	}
 	for _, k2 := range o2Keys {
@@ -173,17 +234,19 @@ func (o1 jsonObject) diff(n JsonNode, path path, metadata []Metadata) Diff {
 				OldValues: nodeList(),
 				NewValues: nodeList(v2),
 			}
-			d = append(d, e)
+			if err := emit(e); err != nil {
+				return err
+			}
 		}
 	}
-	return d
+	return nil
 }
 
 func (o jsonObject) Patch(d Diff) (JsonNode, error) {
 	return patchAll(o, d)
 }
 
-func (o jsonObject) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (o jsonObject) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
 	if (len(pathAhead) == 0) && (len(oldValues) > 1 || len(newValues) > 1) {
 		return patchErrNonSetDiff(oldValues, newValues, pathBehind)
 	}
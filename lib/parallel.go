@@ -0,0 +1,77 @@
+package jd
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// minParallelWidth is the number of independent subtrees below which
+// PARALLEL work is still done serially.
+const minParallelWidth = 32
+
+// parallel reports whether work of the given width should be spread
+// with forEachParallel. Once every helper is busy, nested work is done
+// serially as it would be without PARALLEL.
+func parallel(metadata []Metadata, width int) bool {
+	return width >= minParallelWidth &&
+		checkMetadata(PARALLEL, metadata) &&
+		atomic.LoadInt64(&helpers) < int64(runtime.GOMAXPROCS(0)-1)
+}
+
+// helpers counts the goroutines running PARALLEL work across all diffs.
+// Nested and concurrent forEachParallel calls share the GOMAXPROCS
+// budget instead of each fanning out on their own.
+var helpers int64
+
+// acquireHelper reserves a goroutine from the budget. The goroutine
+// which calls forEachParallel always works too, so the budget is one
+// less than GOMAXPROCS.
+func acquireHelper() bool {
+	if atomic.AddInt64(&helpers, 1) < int64(runtime.GOMAXPROCS(0)) {
+		return true
+	}
+	atomic.AddInt64(&helpers, -1)
+	return false
+}
+
+func releaseHelper() {
+	atomic.AddInt64(&helpers, -1)
+}
+
+// forEachParallel calls fn for each index in [0, n) and waits for all
+// calls to return. The calling goroutine is helped by as many goroutines
+// as the budget has left, so when it is used up, as it is in nested
+// calls, fn is simply called serially.
+func forEachParallel(n int, fn func(i int)) {
+	next := int64(-1)
+	work := func() {
+		for {
+			i := int(atomic.AddInt64(&next, 1))
+			if i >= n {
+				return
+			}
+			fn(i)
+		}
+	}
+	var wg sync.WaitGroup
+	for w := 1; w < n && acquireHelper(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer releaseHelper()
+			work()
+		}()
+	}
+	work()
+	wg.Wait()
+}
+
+func emitAll(d Diff, emit emitFunc) error {
+	for _, e := range d {
+		if err := emit(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package jd
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// wideJson returns an object with n keys each holding a small list of
+// objects, perturbed by seed.
+func wideJson(n, seed int) string {
+	props := make([]string, n)
+	for i := 0; i < n; i++ {
+		elements := make([]string, 0)
+		for j := 0; j < 3; j++ {
+			elements = append(elements, fmt.Sprintf(`{"id":%v,"v":%v}`, j, (i*j+seed)%5))
+		}
+		props[i] = fmt.Sprintf(`"k%03d":[%v]`, (i*7+seed)%(n+10), strings.Join(elements, ","))
+	}
+	return "{" + strings.Join(props, ",") + "}"
+}
+
+func wideList(n, seed int) string {
+	elements := make([]string, n)
+	for i := range elements {
+		elements[i] = fmt.Sprintf(`{"id":%v,"v":[%v,%v]}`, i, (i+seed)%3, i%4)
+	}
+	return "[" + strings.Join(elements, ",") + "]"
+}
+
+func TestParallelDiff(t *testing.T) {
+	cases := []struct {
+		name     string
+		metadata []Metadata
+		a        string
+		b        string
+	}{
+		{"object", nil, wideJson(200, 0), wideJson(200, 1)},
+		{"object of sets", m(SET), wideJson(200, 0), wideJson(200, 1)},
+		{"list", nil, wideList(300, 0), wideList(310, 1)},
+		{"set", m(SET), wideList(300, 0), wideList(310, 1)},
+		{"setkeys", m(SET, Setkeys("id")), wideList(300, 0), wideList(310, 1)},
+		{"multiset", m(MULTISET, Setkeys("id")), wideList(300, 0), wideList(290, 1)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a, err := ReadJsonString(c.a)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			b, err := ReadJsonString(c.b)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			want := a.Diff(b, c.metadata...).Render()
+			if want == "" {
+				t.Fatalf("Want a non-empty diff.")
+			}
+			got := a.Diff(b, append(c.metadata, PARALLEL)...).Render()
+			if got != want {
+				t.Errorf("Parallel diff differs.\nGot:\n%v\nWant:\n%v", got, want)
+			}
+		})
+	}
+}
+
+func TestForEachParallel(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 1000} {
+		seen := make([]int, n)
+		forEachParallel(n, func(i int) {
+			seen[i]++
+		})
+		for i, c := range seen {
+			if c != 1 {
+				t.Errorf("n=%v: index %v visited %v times.", n, i, c)
+			}
+		}
+	}
+}
+
+func TestForEachParallelNested(t *testing.T) {
+	seen := make([][]int, 100)
+	forEachParallel(len(seen), func(i int) {
+		seen[i] = make([]int, 100)
+		forEachParallel(len(seen[i]), func(j int) {
+			seen[i][j]++
+		})
+	})
+	for i := range seen {
+		for j, c := range seen[i] {
+			if c != 1 {
+				t.Errorf("index %v,%v visited %v times.", i, j, c)
+			}
+		}
+	}
+	if h := atomic.LoadInt64(&helpers); h != 0 {
+		t.Errorf("Wanted all helpers released. Got %v busy.", h)
+	}
+}
@@ -7,7 +7,7 @@ import (
 func patchAll(n JsonNode, d Diff) (JsonNode, error) {
 	var err error
 	for _, de := range d {
-		n, err = n.patch(make(path, 0), de.Path, de.OldValues, de.NewValues)
+		n, err = n.patch(make(Path, 0), de.Path, de.OldValues, de.NewValues)
 		if err != nil {
 			return nil, err
 		}
@@ -39,7 +39,7 @@ func patchErrExpectColl(n JsonNode, pe interface{}) (JsonNode, error) {
 
 }
 
-func patchErrNonSetDiff(oldValues, newValues []JsonNode, path path) (JsonNode, error) {
+func patchErrNonSetDiff(oldValues, newValues []JsonNode, path Path) (JsonNode, error) {
 	if len(oldValues) > 1 {
 		return nil, fmt.Errorf(
 			"Invalid diff: Multiple removals from non-set at %v.",
@@ -51,7 +51,7 @@ func patchErrNonSetDiff(oldValues, newValues []JsonNode, path path) (JsonNode, e
 	}
 }
 
-func patchErrExpectValue(want, found JsonNode, path path) (JsonNode, error) {
+func patchErrExpectValue(want, found JsonNode, path Path) (JsonNode, error) {
 	return nil, fmt.Errorf(
 		"Found %v at %v. Expected %v.",
 		found.Json(), path, want.Json())
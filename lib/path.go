@@ -1,8 +1,144 @@
 package jd
 
-type path []JsonNode
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
 
-func (p path) appendIndex(o jsonObject, metadata []Metadata) path {
+// Path is the location of a node within a document. It is a list of
+// object keys (strings), array indices (numbers), set and multiset
+// element identities (objects) and metadata (lists), as written in diff
+// headers.
+type Path []JsonNode
+
+// ParsePath reads a path in the JSON list format of diff headers, e.g.
+// `["foo",0,{}]`.
+func ParsePath(s string) (Path, error) {
+	n, err := ReadJsonString(s)
+	if err != nil {
+		return nil, err
+	}
+	a, ok := n.(jsonArray)
+	if !ok {
+		return nil, fmt.Errorf("Invalid path. Want a JSON list. Got %v.", n.Json())
+	}
+	for _, e := range a {
+		switch e.(type) {
+		case jsonString, jsonNumber, jsonObject, jsonArray:
+		default:
+			return nil, fmt.Errorf("Invalid path element %v.", e.Json())
+		}
+	}
+	return Path(a), nil
+}
+
+// FromPointer reads a JSON Pointer (RFC 6901). The `-` token becomes
+// index -1 (append).
+func FromPointer(s string) (Path, error) {
+	return readPointer(s)
+}
+
+// String returns the path in the JSON list format of diff headers.
+func (p Path) String() string {
+	return jsonArray(p).Json()
+}
+
+// ToPointer returns the path as a JSON Pointer (RFC 6901). Paths with
+// metadata or set elements have no JSON Pointer.
+func (p Path) ToPointer() (string, error) {
+	return writePointer(p)
+}
+
+var jsonPathIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// JSONPath returns the path as a JSONPath expression, e.g. `$.foo[0]`.
+// Set elements with an identity become filter expressions and the set
+// itself becomes a wildcard. Other metadata is dropped.
+func (p Path) JSONPath() (string, error) {
+	var b strings.Builder
+	b.WriteString("$")
+	for i, e := range p {
+		switch e := e.(type) {
+		case jsonString:
+			b.WriteString(jsonPathMember("", string(e)))
+		case jsonNumber:
+			if int(e) < 0 {
+				return "", fmt.Errorf("JSONPath does not support appending.")
+			}
+			fmt.Fprintf(&b, "[%v]", int(e))
+		case jsonObject:
+			if len(e.properties) == 0 {
+				b.WriteString("[*]")
+				continue
+			}
+			keys := make([]string, 0, len(e.properties))
+			for k := range e.properties {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			filters := make([]string, len(keys))
+			for j, k := range keys {
+				filters[j] = jsonPathMember("@", k) + "==" + e.properties[k].Json()
+			}
+			b.WriteString("[?(" + strings.Join(filters, " && ") + ")]")
+		case jsonArray:
+			if _, ok := embeddedFormat(p[i:]); ok {
+				return "", fmt.Errorf("JSONPath does not support embedded content.")
+			}
+		default:
+			return "", fmt.Errorf("Unsupported type: %T", e)
+		}
+	}
+	return b.String(), nil
+}
+
+func jsonPathMember(prefix, key string) string {
+	if jsonPathIdentifier.MatchString(key) {
+		return prefix + "." + key
+	}
+	key = strings.ReplaceAll(key, `\`, `\\`)
+	key = strings.ReplaceAll(key, `'`, `\'`)
+	return prefix + "['" + key + "']"
+}
+
+// HasPrefix reports whether the path starts with all the elements of
+// prefix.
+func (p Path) HasPrefix(prefix Path) bool {
+	if len(prefix) > len(p) {
+		return false
+	}
+	for i, n := range prefix {
+		if !p[i].Equals(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parent returns the path without its last element and the metadata
+// ahead of it. The parent of the root is the root.
+func (p Path) Parent() Path {
+	if len(p) == 0 {
+		return p
+	}
+	i := len(p) - 1
+	if _, ok := embeddedFormat(p[i:]); !ok {
+		for i > 0 {
+			if _, ok := p[i-1].(jsonArray); !ok {
+				break
+			}
+			if _, ok := embeddedFormat(p[i-1:]); ok {
+				break
+			}
+			i--
+		}
+	}
+	return p[:i].clone()
+}
+
+func (p Path) appendIndex(o jsonObject, metadata []Metadata) Path {
 	// Append metadata.
 	meta := make(jsonArray, 0)
 	if checkMetadata(SET, metadata) {
@@ -20,25 +156,21 @@ func (p path) appendIndex(o jsonObject, metadata []Metadata) path {
 	return append(p, o)
 }
 
-func (p path) clone() path {
-	c := make(path, len(p))
+func (p Path) clone() Path {
+	c := make(Path, len(p))
 	copy(c, p)
 	return c
 }
 
-func (p path) next() (JsonNode, []Metadata, path) {
+func (p Path) next() (JsonNode, []Metadata, Path) {
 	var metadata []Metadata
 	for i, n := range p {
 		switch n := n.(type) {
 		case jsonArray:
 			for _, meta := range n {
-				// TODO: parse metadata cleanly.
 				if s, ok := meta.(jsonString); ok {
-					if string(s) == SET.string() {
-						metadata = append(metadata, SET)
-					}
-					if string(s) == MULTISET.string() {
-						metadata = append(metadata, MULTISET)
+					if m := readMetadata(string(s)); m != nil {
+						metadata = append(metadata, m)
 					}
 				}
 				// Ignore unrecognized metadata.
@@ -0,0 +1,138 @@
+package jd
+
+import (
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	cases := []struct {
+		input   string
+		wantErr bool
+	}{
+		{`[]`, false},
+		{`["a",0,{},{"id":1},["set"]]`, false},
+		{`{}`, true},
+		{`"a"`, true},
+		{`[true]`, true},
+		{`[null]`, true},
+		{`[`, true},
+	}
+	for _, c := range cases {
+		p, err := ParsePath(c.input)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("ParsePath(%v) wanted error. Got %v.", c.input, p)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePath(%v) unexpected error: %v", c.input, err)
+			continue
+		}
+		if p.String() != c.input {
+			t.Errorf("ParsePath(%v).String() = %v.", c.input, p.String())
+		}
+	}
+}
+
+func TestPathPointer(t *testing.T) {
+	p, _ := ParsePath(`["a/b",0,"c~d"]`)
+	s, err := p.ToPointer()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if s != `/a~1b/0/c~0d` {
+		t.Errorf("Got %v. Want /a~1b/0/c~0d.", s)
+	}
+	back, err := FromPointer(s)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !jsonArray(back).Equals(jsonArray(p)) {
+		t.Errorf("Got %v. Want %v.", back, p)
+	}
+	p, _ = ParsePath(`["a",["set"],{}]`)
+	if _, err := p.ToPointer(); err == nil {
+		t.Errorf("Wanted error for metadata.")
+	}
+}
+
+func TestPathJSONPath(t *testing.T) {
+	cases := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{`[]`, `$`, false},
+		{`["a",0,"b_1"]`, `$.a[0].b_1`, false},
+		{`["a b","it's",""]`, `$['a b']['it\'s']['']`, false},
+		{`["a",["set"],{}]`, `$.a[*]`, false},
+		{`["a",["multiset","setkeys=id,n"],{"n":1,"id":"x"},"v"]`, `$.a[?(@.id=="x" && @.n==1)].v`, false},
+		{`[{"a b":true}]`, `$[?(@['a b']==true)]`, false},
+		{`["a",-1]`, ``, true},
+		{`["a",["json"],"b"]`, ``, true},
+	}
+	for _, c := range cases {
+		p, err := ParsePath(c.path)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		got, err := p.JSONPath()
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("JSONPath(%v) wanted error. Got %v.", c.path, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("JSONPath(%v) unexpected error: %v", c.path, err)
+		}
+		if got != c.want {
+			t.Errorf("JSONPath(%v) = %v. Want %v.", c.path, got, c.want)
+		}
+	}
+}
+
+func TestPathHasPrefix(t *testing.T) {
+	cases := []struct {
+		path   string
+		prefix string
+		want   bool
+	}{
+		{`[]`, `[]`, true},
+		{`["a",0]`, `[]`, true},
+		{`["a",0]`, `["a"]`, true},
+		{`["a",0]`, `["a",0]`, true},
+		{`["a"]`, `["a",0]`, false},
+		{`["a",0]`, `["b"]`, false},
+		{`["a",["set"],{"id":1}]`, `["a",["set"]]`, true},
+	}
+	for _, c := range cases {
+		p, _ := ParsePath(c.path)
+		prefix, _ := ParsePath(c.prefix)
+		if got := p.HasPrefix(prefix); got != c.want {
+			t.Errorf("%v.HasPrefix(%v) = %v. Want %v.", c.path, c.prefix, got, c.want)
+		}
+	}
+}
+
+func TestPathParent(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+	}{
+		{`[]`, `[]`},
+		{`["a"]`, `[]`},
+		{`["a",0]`, `["a"]`},
+		{`["a",["set","setkeys=id"],{"id":1}]`, `["a"]`},
+		{`["a",["json"]]`, `["a"]`},
+		{`["a",["json"],"b"]`, `["a",["json"]]`},
+		{`["a",["json"],["set"],{}]`, `["a",["json"]]`},
+	}
+	for _, c := range cases {
+		p, _ := ParsePath(c.path)
+		if got := p.Parent().String(); got != c.want {
+			t.Errorf("%v.Parent() = %v. Want %v.", c.path, got, c.want)
+		}
+	}
+}
@@ -8,13 +8,13 @@ import (
 	"github.com/go-openapi/jsonpointer"
 )
 
-func readPointer(s string) ([]JsonNode, error) {
+func readPointer(s string) (Path, error) {
 	pointer, err := jsonpointer.New(s)
 	if err != nil {
 		return nil, err
 	}
 	tokens := pointer.DecodedTokens()
-	path := make([]JsonNode, len(tokens))
+	path := make(Path, len(tokens))
 	for i, t := range tokens {
 		var element JsonNode
 		var err error
@@ -35,7 +35,7 @@ func readPointer(s string) ([]JsonNode, error) {
 	return path, nil
 }
 
-func writePointer(path []JsonNode) (string, error) {
+func writePointer(path Path) (string, error) {
 	var b strings.Builder
 	for _, element := range path {
 		b.WriteString("/")
@@ -47,7 +47,7 @@ func writePointer(path []JsonNode) (string, error) {
 				b.WriteString(jsonpointer.Escape(strconv.Itoa(int(e))))
 			}
 		case jsonString:
-			b.WriteString(string(e))
+			b.WriteString(jsonpointer.Escape(string(e)))
 		case jsonArray:
 			return "", fmt.Errorf("JSON Pointer does not support jd metadata.")
 		default:
@@ -28,6 +28,9 @@ func TestReadPointer(t *testing.T) {
 	}, {
 		input:  `/foo/-/bar`,
 		output: `["foo",-1,"bar"]`,
+	}, {
+		input:  `/a~1b/c~0d`,
+		output: `["a/b","c~d"]`,
 	}}
 
 	for _, tc := range testCases {
@@ -12,13 +12,23 @@ type Metadata interface {
 
 type setMetadata struct{}
 type multisetMetadata struct{}
+type sortMetadata struct{}
+type ignoreCaseMetadata struct{}
+type ignoreWhitespaceMetadata struct{}
+type embeddedMetadata struct{}
+type parallelMetadata struct{}
 type setkeysMetadata struct {
 	keys map[string]bool
 }
 
-func (setMetadata) is_metadata()      {}
-func (multisetMetadata) is_metadata() {}
-func (setkeysMetadata) is_metadata()  {}
+func (setMetadata) is_metadata()              {}
+func (multisetMetadata) is_metadata()         {}
+func (sortMetadata) is_metadata()             {}
+func (ignoreCaseMetadata) is_metadata()       {}
+func (ignoreWhitespaceMetadata) is_metadata() {}
+func (embeddedMetadata) is_metadata()         {}
+func (parallelMetadata) is_metadata()         {}
+func (setkeysMetadata) is_metadata()          {}
 
 func (m setMetadata) string() string {
 	return "set"
@@ -28,19 +38,99 @@ func (m multisetMetadata) string() string {
 	return "multiset"
 }
 
+func (m sortMetadata) string() string {
+	return "sort"
+}
+
+func (m ignoreCaseMetadata) string() string {
+	return "ignorecase"
+}
+
+func (m ignoreWhitespaceMetadata) string() string {
+	return "ignorewhitespace"
+}
+
+func (m embeddedMetadata) string() string {
+	return "embedded"
+}
+
+func (m parallelMetadata) string() string {
+	return "parallel"
+}
+
 func (m setkeysMetadata) string() string {
 	ks := make([]string, 0)
 	for k := range m.keys {
 		ks = append(ks, k)
 	}
 	sort.Strings(ks)
-	// TODO: escape commas.
-	return "setkeys=" + strings.Join(ks, ",")
+	for i, k := range ks {
+		// Escape backslashes first so escaped commas stay unambiguous.
+		k = strings.ReplaceAll(k, `\`, `\\`)
+		ks[i] = strings.ReplaceAll(k, ",", `\,`)
+	}
+	return setkeysPrefix + strings.Join(ks, ",")
+}
+
+const setkeysPrefix = "setkeys="
+
+// readMetadata parses the string form of a single metadata element as
+// written into a diff path. It returns nil for unrecognized metadata.
+func readMetadata(s string) Metadata {
+	switch {
+	case s == SET.string():
+		return SET
+	case s == MULTISET.string():
+		return MULTISET
+	case strings.HasPrefix(s, setkeysPrefix):
+		return Setkeys(splitSetkeys(s[len(setkeysPrefix):])...)
+	}
+	return nil
+}
+
+// splitSetkeys splits comma separated keys, honoring backslash escapes.
+func splitSetkeys(s string) []string {
+	keys := []string{}
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',':
+			keys = append(keys, b.String())
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() > 0 || len(keys) > 0 {
+		keys = append(keys, b.String())
+	}
+	return keys
 }
 
 var (
 	MULTISET Metadata = multisetMetadata{}
 	SET      Metadata = setMetadata{}
+	// SORT renders sets and multisets ordered by the JSON of their
+	// values. Otherwise they keep the order in which values were read,
+	// with patched additions appended.
+	SORT Metadata = sortMetadata{}
+	// IGNORE_CASE compares strings without regard to letter case.
+	IGNORE_CASE Metadata = ignoreCaseMetadata{}
+	// IGNORE_WHITESPACE compares strings without regard to leading and
+	// trailing whitespace or the length of whitespace runs.
+	IGNORE_WHITESPACE Metadata = ignoreWhitespaceMetadata{}
+	// EMBEDDED compares strings holding a JSON or YAML object or array
+	// by their parsed content.
+	EMBEDDED Metadata = embeddedMetadata{}
+	// PARALLEL diffs wide objects and arrays across goroutines and
+	// hashes large sets concurrently. The diff is the same as without it.
+	PARALLEL Metadata = parallelMetadata{}
 )
 
 func Setkeys(keys ...string) Metadata {
@@ -84,3 +174,55 @@ func getSetkeysMetadata(metadata []Metadata) *setkeysMetadata {
 	}
 	return nil
 }
+
+// sortNodes returns the nodes ordered by value when SORT metadata is
+// given. Otherwise it returns the nodes unchanged.
+func sortNodes(nodes []JsonNode, metadata []Metadata) []JsonNode {
+	if !checkMetadata(SORT, metadata) {
+		return nodes
+	}
+	sorted := make([]JsonNode, len(nodes))
+	copy(sorted, nodes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return lessNode(sorted[i], sorted[j], metadata)
+	})
+	return sorted
+}
+
+// sortRank orders kinds: null, booleans, numbers, strings, arrays and
+// then objects.
+var sortRank = map[Kind]int{
+	Void:   0,
+	Null:   1,
+	Bool:   2,
+	Number: 3,
+	String: 4,
+	Array:  5,
+	Object: 6,
+}
+
+// lessNode orders nodes by kind, then numbers numerically, strings and
+// booleans by value and arrays and objects by their JSON.
+func lessNode(a, b JsonNode, metadata []Metadata) bool {
+	ka, kb := KindOf(a), KindOf(b)
+	if ka != kb {
+		return sortRank[ka] < sortRank[kb]
+	}
+	switch ka {
+	case Bool:
+		va, _ := BoolValue(a)
+		vb, _ := BoolValue(b)
+		return !va && vb
+	case Number:
+		va, _ := NumberValue(a)
+		vb, _ := NumberValue(b)
+		return va < vb
+	case String:
+		va, _ := StringValue(a)
+		vb, _ := StringValue(b)
+		return va < vb
+	case Array, Object:
+		return a.Json(metadata...) < b.Json(metadata...)
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package jd
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// randomDoc generates JSON values from a small alphabet so that
+// generated documents share and repeat values. With unique ids, objects
+// in the same array get distinct "id" properties for set keys.
+type randomDoc struct {
+	r         *rand.Rand
+	uniqueIds bool
+}
+
+func (g randomDoc) value(depth int) interface{} {
+	k := g.r.Intn(8)
+	if depth <= 0 {
+		k = g.r.Intn(5)
+	}
+	switch k {
+	case 0:
+		return nil
+	case 1:
+		return g.r.Intn(2) == 0
+	case 2:
+		return float64(g.r.Intn(5))
+	case 3, 4:
+		return []string{"", "a", "b", "a b", "{}"}[g.r.Intn(5)]
+	case 5:
+		return g.array(depth - 1)
+	default:
+		return g.object(depth-1, -1)
+	}
+}
+
+func (g randomDoc) array(depth int) []interface{} {
+	a := make([]interface{}, g.r.Intn(5))
+	for i := range a {
+		if g.r.Intn(2) == 0 {
+			a[i] = g.object(depth, i)
+		} else {
+			a[i] = g.value(depth)
+		}
+	}
+	return a
+}
+
+// object generates an object. Objects in arrays (index >= 0) get an id.
+func (g randomDoc) object(depth int, index int) map[string]interface{} {
+	o := make(map[string]interface{})
+	for i := g.r.Intn(4); i > 0; i-- {
+		o[[]string{"a", "b", "c", "a/b", "~"}[g.r.Intn(5)]] = g.value(depth)
+	}
+	if index >= 0 {
+		if g.uniqueIds {
+			o["id"] = float64(index)
+		} else {
+			o["id"] = float64(g.r.Intn(3))
+		}
+	}
+	return o
+}
+
+// mutate returns a copy of v with random changes.
+func (g randomDoc) mutate(v interface{}, depth int) interface{} {
+	if g.r.Intn(4) == 0 {
+		return g.value(depth)
+	}
+	switch v := v.(type) {
+	case []interface{}:
+		a := make([]interface{}, 0, len(v)+1)
+		for i, e := range v {
+			switch g.r.Intn(5) {
+			case 0:
+				// Drop.
+			case 1:
+				a = append(a, g.mutate(e, depth-1))
+			default:
+				a = append(a, e)
+			}
+			if g.r.Intn(5) == 0 {
+				a = append(a, g.object(depth-1, len(v)+i))
+			}
+		}
+		if g.r.Intn(2) == 0 {
+			g.r.Shuffle(len(a), func(i, j int) { a[i], a[j] = a[j], a[i] })
+		}
+		return a
+	case map[string]interface{}:
+		o := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			switch g.r.Intn(5) {
+			case 0:
+				if k == "id" {
+					o[k] = e
+				}
+			case 1:
+				if k == "id" {
+					o[k] = e
+				} else {
+					o[k] = g.mutate(e, depth-1)
+				}
+			default:
+				o[k] = e
+			}
+		}
+		if g.r.Intn(3) == 0 {
+			o["c"] = g.value(depth - 1)
+		}
+		return o
+	}
+	return v
+}
+
+// randomPair returns two related random JSON documents.
+func randomPair(seed int64, uniqueIds bool) (string, string) {
+	g := randomDoc{
+		r:         rand.New(rand.NewSource(seed)),
+		uniqueIds: uniqueIds,
+	}
+	a := g.value(4)
+	b := g.mutate(a, 4)
+	return genJson(a), genJson(b)
+}
+
+var roundtripMetadata = [][]Metadata{
+	nil,
+	m(SET),
+	m(MULTISET),
+	m(SET, Setkeys("id")),
+	m(MULTISET, Setkeys("id")),
+}
+
+// checkRoundtrip checks that the diff of a and b patches a into b and
+// survives rendering and reading back.
+func checkRoundtrip(t *testing.T, a, b string, metadata []Metadata) {
+	t.Helper()
+	n1, err := ReadJsonString(a)
+	if err != nil {
+		return
+	}
+	n2, err := ReadJsonString(b)
+	if err != nil {
+		return
+	}
+	d := n1.Diff(n2, metadata...)
+	rendered := d.Render()
+	got, err := n1.Patch(d)
+	if err != nil {
+		t.Fatalf("%v\n%v\n%v: patch error: %v\n%v", a, b, metadata, err, rendered)
+	}
+	if !got.Equals(n2, metadata...) {
+		t.Fatalf("%v\n%v\n%v: patched to %v\n%v", a, b, metadata, got.Json(), rendered)
+	}
+	read, err := ReadDiffString(rendered)
+	if err != nil {
+		t.Fatalf("%v\n%v\n%v: read error: %v\n%v", a, b, metadata, err, rendered)
+	}
+	if read.Render() != rendered {
+		t.Fatalf("%v\n%v\n%v: read back as:\n%vWant:\n%v", a, b, metadata, read.Render(), rendered)
+	}
+}
+
+// checkPatchTranslation checks that list diffs translate to JSON Patch
+// and back and still patch a into b.
+func checkPatchTranslation(t *testing.T, a, b string) {
+	t.Helper()
+	n1, err := ReadJsonString(a)
+	if err != nil {
+		return
+	}
+	n2, err := ReadJsonString(b)
+	if err != nil {
+		return
+	}
+	d := n1.Diff(n2)
+	patch, err := d.RenderPatch()
+	if err != nil {
+		t.Fatalf("%v\n%v: render patch error: %v\n%v", a, b, err, d.Render())
+	}
+	back, err := ReadPatchString(patch)
+	if err != nil {
+		t.Fatalf("%v\n%v: read patch error: %v\n%v", a, b, err, patch)
+	}
+	if back.Render() != d.Render() {
+		t.Fatalf("%v\n%v: translated to:\n%vWant:\n%v", a, b, back.Render(), d.Render())
+	}
+	got, err := n1.Patch(back)
+	if err != nil {
+		t.Fatalf("%v\n%v: patch error: %v\n%v", a, b, err, patch)
+	}
+	if !got.Equals(n2) {
+		t.Fatalf("%v\n%v: patched to %v\n%v", a, b, got.Json(), patch)
+	}
+}
+
+func TestRandomRoundtrip(t *testing.T) {
+	for seed := int64(0); seed < 500; seed++ {
+		for _, metadata := range roundtripMetadata {
+			// Set keys must identify objects uniquely within a set.
+			uniqueIds := getSetkeysMetadata(metadata) != nil
+			a, b := randomPair(seed, uniqueIds)
+			t.Run(fmt.Sprintf("%v/%v", seed, metadata), func(t *testing.T) {
+				checkRoundtrip(t, a, b, metadata)
+			})
+		}
+		a, b := randomPair(seed, false)
+		t.Run(fmt.Sprintf("%v/patch", seed), func(t *testing.T) {
+			checkPatchTranslation(t, a, b)
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package jd
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestIssue25(t *testing.T) {
 	// https://github.com/josephburnett/jd/issues/25
-	aNode, _ := ReadYamlString(`
+	a := `
 apiVersion: apps/v1
 kind: Deployment
 metadata:
@@ -26,14 +29,19 @@ spec:
         name: nginx
         ports:
         - containerPort: 8080
-`)
+`
+	aNode, _ := ReadYamlString(a)
 	patch, _ := ReadDiffString(`
-@ ["spec","template","spec","containers",{"name":"nginx"},"ports",0]
+@ ["spec","template","spec","containers",{"name":"nginx"},"ports",0,"containerPort"]
 - 8080
 + 8081
 `)
-	_, err := aNode.Patch(patch)
+	bNode, err := aNode.Patch(patch)
 	if err != nil {
-		t.Errorf("wanted no err. got %v", err)
+		t.Fatalf("wanted no err. got %v", err)
+	}
+	want, _ := ReadYamlString(strings.Replace(a, "8080", "8081", 1))
+	if bNode.Json() != want.Json() {
+		t.Errorf("wanted %v. got %v", want.Json(), bNode.Json())
 	}
 }
@@ -2,7 +2,6 @@ package jd
 
 import (
 	"fmt"
-	"sort"
 )
 
 type jsonSet jsonArray
@@ -18,117 +17,100 @@ func (s jsonSet) Yaml(metadata ...Metadata) string {
 }
 
 func (s jsonSet) raw(metadata []Metadata) interface{} {
-	sMap := make(map[[8]byte]JsonNode)
+	sMap := newNodeMap(metadata, false)
 	for _, n := range s {
-		hc := n.hashCode(metadata)
-		sMap[hc] = n
+		sMap.put(n)
 	}
-	hashes := make(hashCodes, 0, len(sMap))
-	for hc := range sMap {
-		hashes = append(hashes, hc)
+	nodes := make([]JsonNode, 0, sMap.len())
+	for _, e := range sMap.entries() {
+		nodes = append(nodes, e.node)
 	}
-	sort.Sort(hashes)
-	set := make([]interface{}, 0, len(sMap))
-	for _, hc := range hashes {
-		set = append(set, sMap[hc].raw(metadata))
-	}
-	return set
+	return jsonArray(sortNodes(nodes, metadata)).raw(metadata)
 }
 
 func (s1 jsonSet) Equals(n JsonNode, metadata ...Metadata) bool {
-	s2, ok := n.(jsonSet)
+	metadata = withHashMemo(metadata)
+	n2 := dispatch(n, metadata)
+	s2, ok := n2.(jsonSet)
 	if !ok {
 		return false
 	}
-	if s1.hashCode(metadata) == s2.hashCode(metadata) {
-		return true
-	} else {
+	if s1.hashCode(metadata) != s2.hashCode(metadata) {
+		return false
+	}
+	// Same hash codes. Confirm each value is present.
+	s1Map := s1.nodeMap(metadata)
+	s2Map := s2.nodeMap(metadata)
+	if s1Map.len() != s2Map.len() {
 		return false
 	}
+	for _, e := range s1Map.entries() {
+		if s2Map.get(e.node) == nil {
+			return false
+		}
+	}
+	return true
 }
 
-func (s jsonSet) hashCode(metadata []Metadata) [8]byte {
-	sMap := make(map[[8]byte]bool)
+func (s jsonSet) nodeMap(metadata []Metadata) *nodeMap {
+	sMap := newNodeMap(metadata, false)
 	for _, v := range s {
-		v = dispatch(v, metadata)
-		hc := v.hashCode(metadata)
-		sMap[hc] = true
+		sMap.put(dispatch(v, metadata))
 	}
-	hashes := make(hashCodes, 0, len(sMap))
-	for hc := range sMap {
-		hashes = append(hashes, hc)
-	}
-	return hashes.combine()
+	return sMap
+}
+
+func (s jsonSet) hashCode(metadata []Metadata) [8]byte {
+	return memoHash(s, []JsonNode(s), memoSet, metadata, func() [8]byte {
+		sMap := s.nodeMap(metadata)
+		hashes := make(hashCodes, 0, sMap.len())
+		for _, e := range sMap.entries() {
+			hashes = append(hashes, e.hash)
+		}
+		return hashes.combine()
+	})
 }
 
 func (s jsonSet) Diff(j JsonNode, metadata ...Metadata) Diff {
-	return s.diff(j, make(path, 0), metadata)
+	metadata = withHashMemo(metadata)
+	return s.diff(j, make(Path, 0), metadata)
 }
 
-func (s1 jsonSet) diff(n JsonNode, path path, metadata []Metadata) Diff {
+func (s1 jsonSet) diff(n JsonNode, path Path, metadata []Metadata) Diff {
 	d := make(Diff, 0)
 	s2, ok := n.(jsonSet)
 	if !ok {
 		// Different types
 		e := DiffElement{
 			Path:      path.clone(),
-			OldValues: nodeList(s1),
+			OldValues: nodeList(jsonArray(s1)),
 			NewValues: nodeList(n),
 		}
 		return append(d, e)
 	}
-	s1Map := make(map[[8]byte]JsonNode)
-	for _, v := range s1 {
-		var hc [8]byte
-		if o, ok := v.(jsonObject); ok {
-			// Hash objects by their identity.
-			hc = o.ident(metadata)
-		} else {
-			// Everything else by full content.
-			hc = v.hashCode(metadata)
-		}
-		s1Map[hc] = v
-	}
-	s2Map := make(map[[8]byte]JsonNode)
-	for _, v := range s2 {
-		var hc [8]byte
-		if o, ok := v.(jsonObject); ok {
-			// Hash objects by their identity.
-			hc = o.ident(metadata)
-		} else {
-			// Everything else by full content.
-			hc = v.hashCode(metadata)
-		}
-		s2Map[hc] = v
-	}
-	s1Hashes := make(hashCodes, 0)
-	for hc := range s1Map {
-		s1Hashes = append(s1Hashes, hc)
-	}
-	sort.Sort(s1Hashes)
-	s2Hashes := make(hashCodes, 0)
-	for hc := range s2Map {
-		s2Hashes = append(s2Hashes, hc)
-	}
-	sort.Sort(s2Hashes)
+	// Objects by their identity. Everything else by full content.
+	s1Map := newNodeMap(metadata, true)
+	s1Map.putAll(s1)
+	s2Map := newNodeMap(metadata, true)
+	s2Map.putAll(s2)
 	o, _ := NewJsonNode(map[string]interface{}{})
 	e := DiffElement{
 		Path:      path.appendIndex(o.(jsonObject), metadata).clone(),
 		OldValues: nodeList(),
 		NewValues: nodeList(),
 	}
-	for _, hc := range s1Hashes {
-		n2, ok := s2Map[hc]
-		if !ok {
+	for _, e1 := range s1Map.entries() {
+		e2 := s2Map.get(e1.node)
+		if e2 == nil {
 			// Deleted value.
-			e.OldValues = append(e.OldValues, s1Map[hc])
+			e.OldValues = append(e.OldValues, e1.node)
 		} else {
 			// Changed value.
-			o1, isObject1 := s1Map[hc].(jsonObject)
-			o2, isObject2 := n2.(jsonObject)
+			o1, isObject1 := e1.node.(jsonObject)
+			o2, isObject2 := e2.node.(jsonObject)
 			if isObject1 && isObject2 {
 				// Sub diff objects with same identity.
-				p := path.appendIndex(o1, metadata)
+				p := path.appendIndex(o1.identObject(metadata), metadata)
 				subDiff := o1.diff(o2, p, metadata)
 				for _, subElement := range subDiff {
 					d = append(d, subElement)
@@ -136,11 +118,10 @@ func (s1 jsonSet) diff(n JsonNode, path path, metadata []Metadata) Diff {
 			}
 		}
 	}
-	for _, hc := range s2Hashes {
-		_, ok := s1Map[hc]
-		if !ok {
+	for _, e2 := range s2Map.entries() {
+		if s1Map.get(e2.node) == nil {
 			// Added value.
-			e.NewValues = append(e.NewValues, s2Map[hc])
+			e.NewValues = append(e.NewValues, e2.node)
 		}
 	}
 	if len(e.OldValues) > 0 || len(e.NewValues) > 0 {
@@ -153,7 +134,7 @@ func (s jsonSet) Patch(d Diff) (JsonNode, error) {
 	return patchAll(s, d)
 }
 
-func (s jsonSet) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (s jsonSet) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
 	// Base case
 	if len(pathAhead) == 0 {
 		if len(oldValues) > 1 || len(newValues) > 1 {
@@ -175,73 +156,48 @@ func (s jsonSet) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNo
 	}
 	if len(rest) > 0 {
 		// Recurse into a specific object.
-		lookingFor := pathObject.ident(metadata)
-		for _, v := range s {
+		keys := pathIdentKeys(pathObject, metadata)
+		for i, v := range s {
 			if o, ok := v.(jsonObject); ok {
-				id := o.pathIdent(pathObject, metadata)
-				if id == lookingFor {
-					v.patch(append(pathBehind, n), rest, oldValues, newValues)
+				if o.hasPathIdent(pathObject, keys) {
+					patched, err := v.patch(append(pathBehind, n), rest, oldValues, newValues)
+					if err != nil {
+						return nil, err
+					}
+					s[i] = patched
 					return s, nil
 				}
 			}
 		}
 		return nil, fmt.Errorf("Invalid diff. Expected object with id %v but found none", pathObject.Json(metadata...))
 	}
-	// Patch set
-	aMap := make(map[[8]byte]JsonNode)
+	// Patch set. Objects by their identity. Everything else by full
+	// content.
+	aMap := newNodeMap(metadata, true)
 	for _, v := range s {
-		var hc [8]byte
-		if o, ok := v.(jsonObject); ok {
-			// Hash objects by their identitiy.
-			hc = o.ident(metadata)
-		} else {
-			// Everything else by full content.
-			hc = v.hashCode(metadata)
-		}
-		aMap[hc] = v
+		aMap.put(v)
 	}
 	for _, v := range oldValues {
-		var hc [8]byte
-		if o, ok := v.(jsonObject); ok {
-			// Find objects by their identitiy.
-			hc = o.ident(metadata)
-		} else {
-			// Everything else by full content.
-			hc = v.hashCode(metadata)
-		}
-		toDelete, ok := aMap[hc]
-		if !ok {
+		toDelete := aMap.get(v)
+		if toDelete == nil {
 			return nil, fmt.Errorf(
 				"Invalid diff. Expected %v at %v but found nothing.",
 				v.Json(metadata...), pathBehind)
 		}
-		if !toDelete.Equals(v, metadata...) {
+		if !toDelete.node.Equals(v, metadata...) {
 			return nil, fmt.Errorf(
 				"Invalid diff. Expected %v at %v but found %v.",
-				v.Json(metadata...), pathBehind, toDelete.Json(metadata...))
+				v.Json(metadata...), pathBehind, toDelete.node.Json(metadata...))
 
 		}
-		delete(aMap, hc)
+		aMap.delete(v)
 	}
 	for _, v := range newValues {
-		var hc [8]byte
-		if o, ok := v.(jsonObject); ok {
-			// Hash objects by their identitiy.
-			hc = o.ident(metadata)
-		} else {
-			// Everything else by full content.
-			hc = v.hashCode(metadata)
-		}
-		aMap[hc] = v
-	}
-	hashes := make(hashCodes, 0, len(aMap))
-	for hc := range aMap {
-		hashes = append(hashes, hc)
+		aMap.put(v)
 	}
-	sort.Sort(hashes)
-	newValue := make(jsonSet, 0, len(aMap))
-	for _, hc := range hashes {
-		newValue = append(newValue, aMap[hc])
+	newValue := make(jsonSet, 0, aMap.len())
+	for _, e := range aMap.entries() {
+		newValue = append(newValue, e.node)
 	}
 	return newValue, nil
 }
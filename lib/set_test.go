@@ -7,45 +7,70 @@ import (
 func TestSetJson(t *testing.T) {
 	cases := []struct {
 		name     string
-		metadata Metadata
+		metadata []Metadata
 		given    string
 		want     string
 	}{{
 		name:     "array with no space",
-		metadata: SET,
+		metadata: m(SET),
 		given:    `[]`,
 		want:     `[]`,
 	}, {
 		name:     "array with space",
-		metadata: SET,
+		metadata: m(SET),
 		given:    ` [ ] `,
 		want:     `[]`,
 	}, {
 		name:     "array with numbers out of order",
-		metadata: SET,
+		metadata: m(SET),
 		given:    `[2,1,3]`,
-		want:     `[3,2,1]`,
+		want:     `[2,1,3]`,
 	}, {
 		name:     "array with numbers in order",
-		metadata: SET,
+		metadata: m(SET),
 		given:    `[3,2,1]`,
 		want:     `[3,2,1]`,
 	}, {
 		name:     "array with spaced numbers",
-		metadata: SET,
+		metadata: m(SET),
 		given:    ` [1, 2, 3] `,
-		want:     `[3,2,1]`,
+		want:     `[1,2,3]`,
 	}, {
 		name:     "duplicate entries",
-		metadata: SET,
+		metadata: m(SET),
 		given:    `[1,1,1]`,
 		want:     `[1]`,
+	}, {
+		name:     "duplicate entries keep first position",
+		metadata: m(SET),
+		given:    `[2,1,2,3]`,
+		want:     `[2,1,3]`,
+	}, {
+		name:     "sorted numbers",
+		metadata: m(SET, SORT),
+		given:    `[2,1,3]`,
+		want:     `[1,2,3]`,
+	}, {
+		name:     "sorted mixed values",
+		metadata: m(SET, SORT),
+		given:    `["b",{"a":1},"a",[2,1],1]`,
+		want:     `[1,"a","b",[2,1],{"a":1}]`,
+	}, {
+		name:     "sorted multi-digit and negative numbers",
+		metadata: m(SET, SORT),
+		given:    `[10,9,2,"b","a",-1,-20,1.5]`,
+		want:     `[-20,-1,1.5,2,9,10,"a","b"]`,
+	}, {
+		name:     "sorted null and booleans",
+		metadata: m(SET, SORT),
+		given:    `[true,"x",false,null,0]`,
+		want:     `[null,false,true,0,"x"]`,
 	}}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			ctx := newTestContext(t).
-				withMetadata(c.metadata)
+				withMetadata(c.metadata...)
 			checkJson(ctx, c.given, c.want)
 		})
 	}
@@ -218,8 +243,8 @@ func TestSetDiff(t *testing.T) {
 		b:        `["baz"]`,
 		want: ss(
 			`@ [["set"],{}]`,
-			`- "bar"`,
 			`- "foo"`,
+			`- "bar"`,
 			`+ "baz"`,
 		),
 	}, {
@@ -255,6 +280,31 @@ func TestSetDiff(t *testing.T) {
 			`@ [["set","setkeys=id"],{"id":"foo"},"bar"]`,
 			`+ "baz"`,
 		),
+	}, {
+		name: "path identifies object by set keys only",
+		metadata: m(
+			SET,
+			Setkeys("id"),
+		),
+		a: `[{"id":"foo","bar":"baz"}]`,
+		b: `[{"id":"foo","bar":"zap"}]`,
+		want: ss(
+			`@ [["set","setkeys=id"],{"id":"foo"},"bar"]`,
+			`- "baz"`,
+			`+ "zap"`,
+		),
+	}, {
+		name: "set keys with commas are escaped",
+		metadata: m(
+			SET,
+			Setkeys("a,b", `c\d`),
+		),
+		a: `[{"a,b":"foo"}]`,
+		b: `[{"a,b":"foo","bar":"baz"}]`,
+		want: ss(
+			`@ [["set","setkeys=a\\,b,c\\\\d"],{"a,b":"foo"},"bar"]`,
+			`+ "baz"`,
+		),
 	}, {
 		name: "find object by id among empty objects",
 		metadata: m(
@@ -304,6 +354,27 @@ func TestSetDiff(t *testing.T) {
 			`- {"id":"foo"}`,
 			`+ {"id":"bar"}`,
 		),
+	}, {
+		name: "objects with swapped set key values are distinct",
+		metadata: m(
+			SET,
+			Setkeys("a", "b"),
+		),
+		a: `[{"a":1,"b":2}]`,
+		b: `[{"a":1,"b":2},{"a":2,"b":1}]`,
+		want: ss(
+			`@ [["set","setkeys=a,b"],{}]`,
+			`+ {"a":2,"b":1}`,
+		),
+	}, {
+		name:     "set membership ignores case",
+		metadata: m(SET, IGNORE_CASE),
+		a:        `["Foo","bar"]`,
+		b:        `["foo","BAR","baz"]`,
+		want: ss(
+			`@ [["set"],{}]`,
+			`+ "baz"`,
+		),
 	}, {
 		name:     "set metadata applies to array in object",
 		metadata: m(SET),
@@ -446,6 +517,27 @@ func TestSetPatch(t *testing.T) {
 			`+ "zap"`,
 		),
 		want: `[{"id":"foo","baz":"zap"},{"id":"bar"}]`,
+	}, {
+		name:     "patch object by set keys ignoring other properties",
+		metadata: SET,
+		given:    `[{"id":"foo","bar":"baz"},{"id":"zip","bar":"baz"}]`,
+		patch: ss(
+			`@ [["set","setkeys=id"],{"id":"foo"},"bar"]`,
+			`- "baz"`,
+			`+ "zap"`,
+			`@ [["set","setkeys=id"],{"id":"foo"},"qux"]`,
+			`+ "zap"`,
+		),
+		want: `[{"id":"foo","bar":"zap","qux":"zap"},{"id":"zip","bar":"baz"}]`,
+	}, {
+		name:     "patch object by escaped set keys",
+		metadata: SET,
+		given:    `[{"a,b":"foo"},{"a,b":"bar"}]`,
+		patch: ss(
+			`@ [["set","setkeys=a\\,b"],{"a,b":"foo"},"c"]`,
+			`+ "baz"`,
+		),
+		want: `[{"a,b":"foo","c":"baz"},{"a,b":"bar"}]`,
 	}, {
 		name:     "replace two objects with diffent ids",
 		metadata: SET,
@@ -467,6 +559,81 @@ func TestSetPatch(t *testing.T) {
 	}
 }
 
+func TestSetPatchOrder(t *testing.T) {
+	cases := []struct {
+		name     string
+		metadata []Metadata
+		given    string
+		patch    []string
+		want     string
+	}{{
+		name:     "additions are appended",
+		metadata: m(SET),
+		given:    `[3,1,2]`,
+		patch: ss(
+			`@ [["set"],{}]`,
+			`- 1`,
+			`+ 0`,
+		),
+		want: `[3,2,0]`,
+	}, {
+		name:     "sorted additions",
+		metadata: m(SET, SORT),
+		given:    `[3,1,2]`,
+		patch: ss(
+			`@ [["set"],{}]`,
+			`- 1`,
+			`+ 0`,
+		),
+		want: `[0,2,3]`,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			checkPatchJson(t, c.given, c.want, c.metadata, c.patch...)
+		})
+	}
+}
+
+func TestSetkeysMetadataString(t *testing.T) {
+	cases := []struct {
+		keys []string
+		want string
+	}{{
+		keys: ss("id"),
+		want: `setkeys=id`,
+	}, {
+		keys: ss("b", "a"),
+		want: `setkeys=a,b`,
+	}, {
+		keys: ss("a,b"),
+		want: `setkeys=a\,b`,
+	}, {
+		keys: ss(`a\`, "b"),
+		want: `setkeys=a\\,b`,
+	}}
+
+	for _, c := range cases {
+		got := Setkeys(c.keys...).string()
+		if got != c.want {
+			t.Errorf("Setkeys(%q).string() = %q. Want %q.", c.keys, got, c.want)
+		}
+		m := readMetadata(got)
+		sk, ok := m.(setkeysMetadata)
+		if !ok {
+			t.Fatalf("readMetadata(%q) = %v. Want setkeys.", got, m)
+		}
+		if len(sk.keys) != len(c.keys) {
+			t.Errorf("readMetadata(%q) = %v. Want %q.", got, sk.keys, c.keys)
+		}
+		for _, k := range c.keys {
+			if !sk.keys[k] {
+				t.Errorf("readMetadata(%q) missing key %q.", got, k)
+			}
+		}
+	}
+}
+
 func TestSetPatchError(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -1,5 +1,10 @@
 package jd
 
+import (
+	"fmt"
+	"strings"
+)
+
 type jsonString string
 
 var _ JsonNode = jsonString("")
@@ -21,22 +26,101 @@ func (s1 jsonString) Equals(n JsonNode, metadata ...Metadata) bool {
 	if !ok {
 		return false
 	}
-	return s1 == s2
+	if e1, f1 := s1.embedded(metadata); e1 != nil {
+		if e2, f2 := s2.embedded(metadata); e2 != nil && f1 == f2 {
+			return e1.Equals(e2, metadata...)
+		}
+	}
+	return s1.normalize(metadata) == s2.normalize(metadata)
 }
 
 func (s jsonString) hashCode(metadata []Metadata) [8]byte {
-	return hash([]byte(s))
+	if e, _ := s.embedded(metadata); e != nil {
+		return e.hashCode(metadata)
+	}
+	return hash([]byte(s.normalize(metadata)))
+}
+
+// Embedded formats are written into diff paths as metadata ahead of the
+// path into the parsed content of a string.
+const (
+	embeddedJson = "json"
+	embeddedYaml = "yaml"
+)
+
+// embedded parses a string holding a JSON object or array, or a
+// multi-line YAML mapping or sequence, when EMBEDDED metadata is given. It
+// returns the parsed node and its format, or nil if the string doesn't
+// hold embedded content.
+func (s jsonString) embedded(metadata []Metadata) (JsonNode, string) {
+	if !checkMetadata(EMBEDDED, metadata) {
+		return nil, ""
+	}
+	return parseEmbedded(string(s))
+}
+
+func parseEmbedded(s string) (JsonNode, string) {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if n, err := ReadJsonString(s); err == nil {
+			return n, embeddedJson
+		}
+	}
+	if strings.Contains(trimmed, "\n") {
+		// YAML with non-string keys such as `y` or `on` fails to
+		// read and stays a plain string. Re-rendering it would change
+		// keys the diff never touched.
+		if n, err := ReadYamlString(s); err == nil {
+			switch n.(type) {
+			case jsonObject, jsonArray:
+				return n, embeddedYaml
+			}
+		}
+	}
+	return nil, ""
+}
+
+func renderEmbedded(n JsonNode, format string) JsonNode {
+	if isVoid(n) {
+		return n
+	}
+	if format == embeddedYaml {
+		return jsonString(n.Yaml())
+	}
+	return jsonString(n.Json())
+}
+
+// normalize returns the form of the string which is compared under the
+// given metadata.
+func (s jsonString) normalize(metadata []Metadata) string {
+	str := string(s)
+	if checkMetadata(IGNORE_WHITESPACE, metadata) {
+		str = strings.Join(strings.Fields(str), " ")
+	}
+	if checkMetadata(IGNORE_CASE, metadata) {
+		str = strings.ToLower(str)
+	}
+	return str
 }
 
 func (s jsonString) Diff(n JsonNode, metadata ...Metadata) Diff {
-	return s.diff(n, make(path, 0), metadata)
+	return s.diff(n, make(Path, 0), metadata)
 }
 
-func (s1 jsonString) diff(n JsonNode, path path, metadata []Metadata) Diff {
+func (s1 jsonString) diff(n JsonNode, path Path, metadata []Metadata) Diff {
 	d := make(Diff, 0)
-	if s1.Equals(n) {
+	if s1.Equals(n, metadata...) {
 		return d
 	}
+	if s2, ok := n.(jsonString); ok {
+		e1, f1 := s1.embedded(metadata)
+		e2, f2 := s2.embedded(metadata)
+		if e1 != nil && e2 != nil && f1 == f2 {
+			// Diff embedded content structurally.
+			p := append(path, jsonArray{jsonString(f1)})
+			return e1.diff(e2, p, metadata)
+		}
+	}
 	e := DiffElement{
 		Path:      path.clone(),
 		OldValues: nodeList(s1),
@@ -49,7 +133,21 @@ func (s jsonString) Patch(d Diff) (JsonNode, error) {
 	return patchAll(s, d)
 }
 
-func (s jsonString) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (s jsonString) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
+	if format, ok := embeddedFormat(pathAhead); ok {
+		// Patch embedded content and serialize it back into the string.
+		n, f := parseEmbedded(string(s))
+		if n == nil || f != format {
+			return nil, fmt.Errorf(
+				"Found %v at %v. Expected embedded %v.",
+				s.Json(), pathBehind, format)
+		}
+		patched, err := n.patch(append(pathBehind, pathAhead[0]), pathAhead[1:], oldValues, newValues)
+		if err != nil {
+			return nil, err
+		}
+		return renderEmbedded(patched, format), nil
+	}
 	if len(pathAhead) != 0 {
 		return patchErrExpectColl(s, pathBehind[0])
 	}
@@ -63,3 +161,22 @@ func (s jsonString) patch(pathBehind, pathAhead path, oldValues, newValues []Jso
 	}
 	return newValue, nil
 }
+
+// embeddedFormat returns the embedded format named by path metadata at the
+// start of the path, if any.
+func embeddedFormat(p Path) (string, bool) {
+	if len(p) == 0 {
+		return "", false
+	}
+	meta, ok := p[0].(jsonArray)
+	if !ok || len(meta) != 1 {
+		return "", false
+	}
+	switch meta[0] {
+	case jsonString(embeddedJson):
+		return embeddedJson, true
+	case jsonString(embeddedYaml):
+		return embeddedYaml, true
+	}
+	return "", false
+}
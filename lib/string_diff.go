@@ -0,0 +1,126 @@
+package jd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stringEdit is one token of a string sub-diff. Op is ' ' for a token
+// present in both strings, '-' for a removed token and '+' for an added
+// token.
+type stringEdit struct {
+	op    byte
+	token string
+}
+
+// maxStringDiffCells bounds the size of the longest common subsequence
+// table. Larger changes are shown as a single removal and addition.
+const maxStringDiffCells = 4 * 1024 * 1024
+
+// diffTokens computes a minimal edit script between two token lists.
+func diffTokens(a, b []string) []stringEdit {
+	edits := []stringEdit{}
+	// Common prefix.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		edits = append(edits, stringEdit{' ', a[prefix]})
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+	// Common suffix.
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	tail := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+	if len(a)*len(b) > maxStringDiffCells {
+		for _, t := range a {
+			edits = append(edits, stringEdit{'-', t})
+		}
+		for _, t := range b {
+			edits = append(edits, stringEdit{'+', t})
+		}
+	} else {
+		edits = append(edits, diffTokensLcs(a, b)...)
+	}
+	for _, t := range tail {
+		edits = append(edits, stringEdit{' ', t})
+	}
+	return edits
+}
+
+func diffTokensLcs(a, b []string) []stringEdit {
+	// lcs[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	edits := make([]stringEdit, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, stringEdit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, stringEdit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, stringEdit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, stringEdit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, stringEdit{'+', b[j]})
+	}
+	return edits
+}
+
+func isMultiline(s string) bool {
+	return strings.Contains(s, "\n")
+}
+
+// splitWords splits a string into alternating runs of whitespace and
+// non-whitespace. Strings without whitespace are split into characters.
+func splitWords(s string) []string {
+	if strings.IndexFunc(s, unicode.IsSpace) == -1 {
+		chars := make([]string, 0, len(s))
+		for _, r := range s {
+			chars = append(chars, string(r))
+		}
+		return chars
+	}
+	words := []string{}
+	start := 0
+	var inSpace bool
+	for i, r := range s {
+		space := unicode.IsSpace(r)
+		if i > start && space != inSpace {
+			words = append(words, s[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
@@ -0,0 +1,68 @@
+package jd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffTokens(t *testing.T) {
+	cases := []struct {
+		a    string
+		b    string
+		want string
+	}{{
+		a:    ``,
+		b:    ``,
+		want: ``,
+	}, {
+		a:    `abc`,
+		b:    `abc`,
+		want: ` a b c`,
+	}, {
+		a:    `abc`,
+		b:    `axc`,
+		want: ` a-b+x c`,
+	}, {
+		a:    `abcd`,
+		b:    `acbd`,
+		want: ` a-b c+b d`,
+	}, {
+		a:    ``,
+		b:    `ab`,
+		want: `+a+b`,
+	}}
+
+	for _, c := range cases {
+		edits := diffTokens(strings.Split(c.a, ""), strings.Split(c.b, ""))
+		got := ""
+		for _, e := range edits {
+			got += string(e.op) + e.token
+		}
+		if got != c.want {
+			t.Errorf("diffTokens(%q, %q) = %q. Want %q.", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	cases := []struct {
+		s    string
+		want []string
+	}{{
+		s:    `abc`,
+		want: ss("a", "b", "c"),
+	}, {
+		s:    `a b  c`,
+		want: ss("a", " ", "b", "  ", "c"),
+	}, {
+		s:    ` ab `,
+		want: ss(" ", "ab", " "),
+	}}
+
+	for _, c := range cases {
+		got := splitWords(c.s)
+		if strings.Join(got, "|") != strings.Join(c.want, "|") {
+			t.Errorf("splitWords(%q) = %q. Want %q.", c.s, got, c.want)
+		}
+	}
+}
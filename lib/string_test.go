@@ -18,6 +18,25 @@ func TestStringEqual(t *testing.T) {
 	checkEqual(ctx, `"123"`, `"123"`)
 }
 
+func TestStringEqualIgnoring(t *testing.T) {
+	ctx := newTestContext(t).withMetadata(IGNORE_CASE)
+	checkEqual(ctx, `"abc"`, `"ABC"`)
+	checkEqual(ctx, `"Foo@Example.com"`, `"foo@example.COM"`)
+	ctx = newTestContext(t).withMetadata(IGNORE_WHITESPACE)
+	checkEqual(ctx, `"a b"`, `" a  b\n"`)
+	checkEqual(ctx, `"a\tb"`, `"a b"`)
+	ctx = newTestContext(t).withMetadata(IGNORE_CASE, IGNORE_WHITESPACE)
+	checkEqual(ctx, `"Hello World"`, `"hello   world\n"`)
+}
+
+func TestStringNotEqualIgnoring(t *testing.T) {
+	ctx := newTestContext(t).withMetadata(IGNORE_CASE)
+	checkNotEqual(ctx, `"abc"`, `"ab c"`)
+	ctx = newTestContext(t).withMetadata(IGNORE_WHITESPACE)
+	checkNotEqual(ctx, `"abc"`, `"ABC"`)
+	checkNotEqual(ctx, `"ab"`, `"a b"`)
+}
+
 func TestStringNotEqual(t *testing.T) {
 	ctx := newTestContext(t)
 	checkNotEqual(ctx, `""`, `"a"`)
@@ -34,6 +53,13 @@ func TestStringHash(t *testing.T) {
 	checkHash(ctx, `"abc"`, `"123"`, false)
 }
 
+func TestStringHashIgnoring(t *testing.T) {
+	ctx := newTestContext(t).withMetadata(IGNORE_CASE, IGNORE_WHITESPACE)
+	checkHash(ctx, `"abc"`, `"ABC"`, true)
+	checkHash(ctx, `"a b"`, `" A\tB "`, true)
+	checkHash(ctx, `"abc"`, `"ab c"`, false)
+}
+
 func TestStringDiff(t *testing.T) {
 	ctx := newTestContext(t)
 	checkDiff(ctx, `""`, `""`)
@@ -47,6 +73,16 @@ func TestStringDiff(t *testing.T) {
 		`+ "abc"`)
 }
 
+func TestStringDiffIgnoring(t *testing.T) {
+	ctx := newTestContext(t).withMetadata(IGNORE_CASE, IGNORE_WHITESPACE)
+	checkDiff(ctx, `"abc"`, `" ABC\n"`)
+	checkDiff(ctx, `{"a":"x"}`, `{"a":"X"}`)
+	checkDiff(ctx, `"abc"`, `"abd"`,
+		`@ []`,
+		`- "abc"`,
+		`+ "abd"`)
+}
+
 func TestStringPatch(t *testing.T) {
 	ctx := newTestContext(t)
 	checkPatch(ctx, `""`, `""`)
@@ -70,3 +106,90 @@ func TestStringPatchError(t *testing.T) {
 		`@ []`,
 		`+ "a"`)
 }
+
+func TestStringEmbedded(t *testing.T) {
+	ctx := newTestContext(t).withMetadata(EMBEDDED)
+	checkEqual(ctx, `"{\"a\":1,\"b\":2}"`, `"{ \"b\": 2, \"a\": 1 }"`)
+	checkEqual(ctx, `"a: 1\nb: 2\n"`, `"b: 2\na: 1\n"`)
+	checkNotEqual(ctx, `"{\"a\":1}"`, `"{\"a\":2}"`)
+	checkHash(ctx, `"{\"a\":1,\"b\":2}"`, `"{ \"b\": 2, \"a\": 1 }"`, true)
+	checkDiff(ctx,
+		`{"config":"{\"a\":1,\"b\":[1,2]}"}`,
+		`{"config":"{\"a\":2,\"b\":[1,2]}"}`,
+		`@ ["config",["json"],"a"]`,
+		`- 1`,
+		`+ 2`)
+	checkDiff(ctx,
+		`"a: 1\nb: 2\n"`,
+		`"a: 1\nb: 3\n"`,
+		`@ [["yaml"],"b"]`,
+		`- 2`,
+		`+ 3`)
+	checkDiff(ctx,
+		`"{\"a\":1}"`,
+		`"not json"`,
+		`@ []`,
+		`- "{\"a\":1}"`,
+		`+ "not json"`)
+	checkPatch(ctx,
+		`{"config":"{\"a\":1,\"b\":[1,2]}"}`,
+		`{"config":"{\"a\":2,\"b\":[1,2]}"}`,
+		`@ ["config",["json"],"a"]`,
+		`- 1`,
+		`+ 2`)
+	checkPatch(ctx,
+		`"a: 1\nb: 2\n"`,
+		`"a: 1\nb: 3\n"`,
+		`@ [["yaml"],"b"]`,
+		`- 2`,
+		`+ 3`)
+	checkPatchError(ctx,
+		`"not json"`,
+		`@ [["json"],"a"]`,
+		`- 1`,
+		`+ 2`)
+}
+
+func TestStringEmbeddedYamlNonStringKeys(t *testing.T) {
+	ctx := newTestContext(t).withMetadata(EMBEDDED)
+	checkDiff(ctx,
+		`{"a":"x: 1\ny: 2\n"}`,
+		`{"a":"x: 1\ny: 3\n"}`,
+		`@ ["a"]`,
+		`- "x: 1\ny: 2\n"`,
+		`+ "x: 1\ny: 3\n"`)
+	checkPatch(ctx,
+		`{"a":"x: 1\ny: 2\n"}`,
+		`{"a":"x: 1\ny: 3\n"}`,
+		`@ ["a"]`,
+		`- "x: 1\ny: 2\n"`,
+		`+ "x: 1\ny: 3\n"`)
+	checkPatch(ctx,
+		`{"a":"on: push\nname: ci\n"}`,
+		`{"a":"on: pull_request\nname: ci\n"}`,
+		`@ ["a"]`,
+		`- "on: push\nname: ci\n"`,
+		`+ "on: pull_request\nname: ci\n"`)
+	checkPatch(ctx,
+		`{"a":"\"y\": 2\nx: 1\n"}`,
+		`{"a":"\"y\": 3\nx: 1\n"}`,
+		`@ ["a",["yaml"],"y"]`,
+		`- 2`,
+		`+ 3`)
+}
+
+func TestStringEmbeddedSet(t *testing.T) {
+	ctx := newTestContext(t).withMetadata(EMBEDDED, SET)
+	checkDiff(ctx,
+		`{"tags":"[\"a\",\"b\"]"}`,
+		`{"tags":"[\"b\",\"c\"]"}`,
+		`@ ["tags",["json"],["set"],{}]`,
+		`- "a"`,
+		`+ "c"`)
+	checkPatch(ctx,
+		`{"tags":"[\"a\",\"b\"]"}`,
+		`{"tags":"[\"b\",\"c\"]"}`,
+		`@ ["tags",["json"],["set"],{}]`,
+		`- "a"`,
+		`+ "c"`)
+}
@@ -40,10 +40,10 @@ func (v voidNode) hashCode(metadata []Metadata) [8]byte {
 }
 
 func (v voidNode) Diff(n JsonNode, metadata ...Metadata) Diff {
-	return v.diff(n, make(path, 0), metadata)
+	return v.diff(n, make(Path, 0), metadata)
 }
 
-func (v voidNode) diff(n JsonNode, p path, metadata []Metadata) Diff {
+func (v voidNode) diff(n JsonNode, p Path, metadata []Metadata) Diff {
 	d := make(Diff, 0)
 	if v.Equals(n) {
 		return d
@@ -60,7 +60,7 @@ func (v voidNode) Patch(d Diff) (JsonNode, error) {
 	return patchAll(v, d)
 }
 
-func (v voidNode) patch(pathBehind, pathAhead path, oldValues, newValues []JsonNode) (JsonNode, error) {
+func (v voidNode) patch(pathBehind, pathAhead Path, oldValues, newValues []JsonNode) (JsonNode, error) {
 	if len(pathAhead) != 0 {
 		return patchErrExpectColl(v, pathBehind[len(pathBehind)-1])
 	}
@@ -0,0 +1,115 @@
+package jd
+
+import (
+	"errors"
+	"sort"
+)
+
+// SkipNode is returned by a walk function to skip the subtree of the
+// current node. It is not returned as an error by Walk.
+var SkipNode = errors.New("skip this node")
+
+// WalkFunc is called by Walk for each node with its path. A returned
+// error stops the walk, except for SkipNode.
+type WalkFunc func(path Path, n JsonNode) error
+
+// Walk visits a node and all its descendants in depth-first order. Object
+// properties are visited in key order. Arrays are visited as lists, sets
+// or multisets according to metadata. List elements are addressed by
+// index. Set and multiset elements are addressed like diff paths: objects
+// by their identity and everything else by the set itself. With EMBEDDED
+// metadata, strings holding JSON or YAML are walked into.
+func Walk(n JsonNode, fn WalkFunc, metadata ...Metadata) error {
+	err := walk(make(Path, 0), n, fn, metadata)
+	if err == SkipNode {
+		return nil
+	}
+	return err
+}
+
+func walk(path Path, n JsonNode, fn WalkFunc, metadata []Metadata) error {
+	n = dispatch(n, metadata)
+	if err := fn(path.clone(), n); err != nil {
+		return err
+	}
+	switch n := n.(type) {
+	case jsonObject:
+		keys := make([]string, 0, len(n.properties))
+		for k := range n.properties {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			err := walk(append(path, jsonString(k)), n.properties[k], fn, metadata)
+			if err != nil && err != SkipNode {
+				return err
+			}
+		}
+	case jsonList:
+		for i, e := range n {
+			err := walk(append(path, jsonNumber(i)), e, fn, metadata)
+			if err != nil && err != SkipNode {
+				return err
+			}
+		}
+	case jsonSet:
+		return walkSet(path, n, fn, metadata)
+	case jsonMultiset:
+		return walkSet(path, n, fn, metadata)
+	case jsonString:
+		if e, f := n.embedded(metadata); e != nil {
+			err := walk(append(path, jsonArray{jsonString(f)}), e, fn, metadata)
+			if err != nil && err != SkipNode {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func walkSet(path Path, elements []JsonNode, fn WalkFunc, metadata []Metadata) error {
+	empty := jsonObject{
+		properties: make(map[string]JsonNode),
+		idKeys:     make(map[string]bool),
+	}
+	for _, e := range elements {
+		p := path.appendIndex(empty, metadata)
+		if o, ok := e.(jsonObject); ok {
+			p = path.appendIndex(o.identObject(metadata), metadata)
+		}
+		err := walk(p, e, fn, metadata)
+		if err != nil && err != SkipNode {
+			return err
+		}
+	}
+	return nil
+}
+
+// Walk calls fn for each element of the diff in order. When fn returns
+// SkipNode, following elements under the path of the skipped element are
+// not visited. Any other error stops the walk.
+func (d Diff) Walk(fn func(path Path, e DiffElement) error) error {
+	var skipped []Path
+	for _, e := range d {
+		path := e.Path
+		skip := false
+		for _, s := range skipped {
+			if path.HasPrefix(s) {
+				skip = true
+				break
+			}
+		}
+		if skip {
+			continue
+		}
+		err := fn(path.clone(), e)
+		if err == SkipNode {
+			skipped = append(skipped, path)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
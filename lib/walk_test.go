@@ -0,0 +1,162 @@
+package jd
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	cases := []struct {
+		name     string
+		metadata []Metadata
+		json     string
+		skip     string
+		want     []string
+	}{{
+		name: "scalar",
+		json: `1`,
+		want: []string{`[] 1`},
+	}, {
+		name: "object in key order",
+		json: `{"b":2,"a":{"c":null}}`,
+		want: []string{
+			`[] {"a":{"c":null},"b":2}`,
+			`["a"] {"c":null}`,
+			`["a","c"] null`,
+			`["b"] 2`,
+		},
+	}, {
+		name: "list",
+		json: `[1,[2]]`,
+		want: []string{
+			`[] [1,[2]]`,
+			`[0] 1`,
+			`[1] [2]`,
+			`[1,0] 2`,
+		},
+	}, {
+		name:     "set",
+		metadata: m(SET),
+		json:     `[1,{"a":2}]`,
+		want: []string{
+			`[] [1,{"a":2}]`,
+			`[["set"],{}] 1`,
+			`[["set"],{"a":2}] {"a":2}`,
+			`[["set"],{"a":2},"a"] 2`,
+		},
+	}, {
+		name:     "multiset with setkeys",
+		metadata: m(MULTISET, Setkeys("id")),
+		json:     `[{"id":1,"v":2}]`,
+		want: []string{
+			`[] [{"id":1,"v":2}]`,
+			`[["multiset","setkeys=id"],{"id":1}] {"id":1,"v":2}`,
+			`[["multiset","setkeys=id"],{"id":1},"id"] 1`,
+			`[["multiset","setkeys=id"],{"id":1},"v"] 2`,
+		},
+	}, {
+		name:     "embedded",
+		metadata: m(EMBEDDED),
+		json:     `{"a":"[1]"}`,
+		want: []string{
+			`[] {"a":"[1]"}`,
+			`["a"] "[1]"`,
+			`["a",["json"]] [1]`,
+			`["a",["json"],0] 1`,
+		},
+	}, {
+		name: "skip",
+		json: `{"a":{"b":1},"c":2}`,
+		skip: `["a"]`,
+		want: []string{
+			`[] {"a":{"b":1},"c":2}`,
+			`["a"] {"b":1}`,
+			`["c"] 2`,
+		},
+	}, {
+		name: "skip root",
+		json: `[1,2]`,
+		skip: `[]`,
+		want: []string{
+			`[] [1,2]`,
+		},
+	}}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n, err := ReadJsonString(c.json)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			got := []string{}
+			err = Walk(n, func(p Path, n JsonNode) error {
+				ps := jsonArray(p).Json()
+				got = append(got, ps+" "+n.Json())
+				if ps == c.skip {
+					return SkipNode
+				}
+				return nil
+			}, c.metadata...)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("Got %q. Want %q.", got, c.want)
+			}
+		})
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	n, _ := ReadJsonString(`[1,2,3]`)
+	visited := 0
+	err := Walk(n, func(p Path, n JsonNode) error {
+		visited++
+		if n.Equals(jsonNumber(2)) {
+			return fmt.Errorf("found 2")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "found 2" {
+		t.Errorf("Want error found 2. Got %v.", err)
+	}
+	if visited != 3 {
+		t.Errorf("Want 3 visited nodes. Got %v.", visited)
+	}
+}
+
+func TestDiffWalk(t *testing.T) {
+	d, err := ReadDiffString(
+		`@ ["a","b"]` + "\n" +
+			`- 1` + "\n" +
+			`@ ["a","c"]` + "\n" +
+			`+ 2` + "\n" +
+			`@ ["d"]` + "\n" +
+			`- 3` + "\n")
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	var got []string
+	err = d.Walk(func(p Path, e DiffElement) error {
+		got = append(got, jsonArray(p).Json())
+		if jsonArray(p).Json() == `["a","b"]` {
+			return SkipNode
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	want := []string{`["a","b"]`, `["a","c"]`, `["d"]`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v. Want %v.", got, want)
+	}
+	got = nil
+	err = d.Walk(func(p Path, e DiffElement) error {
+		got = append(got, jsonArray(p).Json())
+		return fmt.Errorf("stop")
+	})
+	if err == nil || len(got) != 1 {
+		t.Errorf("Want walk to stop at the first element. Got %v, %v.", got, err)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -17,13 +19,18 @@ import (
 
 const version = "HEAD"
 
-var format = flag.String("f", "", "Diff format (jd, patch)")
+var embedded = flag.Bool("embedded", false, "Diff JSON and YAML in strings structurally")
+var format = flag.String("f", "", "Diff format (jd, patch, human)")
+var ignoreCase = flag.Bool("ignorecase", false, "Compare strings ignoring case")
+var ignoreWhitespace = flag.Bool("ignorewhitespace", false, "Compare strings ignoring whitespace")
 var mset = flag.Bool("mset", false, "Arrays as multisets")
 var output = flag.String("o", "", "Output file")
+var parallel = flag.Bool("parallel", false, "Diff across all CPUs")
 var patch = flag.Bool("p", false, "Patch mode")
 var port = flag.Int("port", 0, "Serve web UI on port")
 var set = flag.Bool("set", false, "Arrays as sets")
 var setkeys = flag.String("setkeys", "", "Keys to identify set objects")
+var sortSets = flag.Bool("sort", false, "Sort sets and multisets by value")
 var translate = flag.String("t", "", "Translate mode")
 var ver = flag.Bool("version", false, "Print version and exit")
 var yaml = flag.Bool("yaml", false, "Read and write YAML")
@@ -55,25 +62,25 @@ func main() {
 	if *patch && *translate != "" {
 		errorAndExit("Patch and translate modes cannot be used together.")
 	}
-	var a, b string
+	var a, b io.Reader
 	switch mode {
 	case diffMode, patchMode:
 		switch len(flag.Args()) {
 		case 1:
-			a = readFile(flag.Arg(0))
-			b = readStdin()
+			a = openFile(flag.Arg(0))
+			b = bufio.NewReader(os.Stdin)
 		case 2:
-			a = readFile(flag.Arg(0))
-			b = readFile(flag.Arg(1))
+			a = openFile(flag.Arg(0))
+			b = openFile(flag.Arg(1))
 		default:
 			printUsageAndExit()
 		}
 	case translateMode:
 		switch len(flag.Args()) {
 		case 0:
-			a = readStdin()
+			a = bufio.NewReader(os.Stdin)
 		case 1:
-			a = readFile(flag.Arg(0))
+			a = openFile(flag.Arg(0))
 		default:
 			printUsageAndExit()
 		}
@@ -97,10 +104,12 @@ const (
 )
 
 func serveWeb(port string) error {
+	serve.RegisterApi(http.DefaultServeMux)
 	if serve.Handle == nil {
-		return fmt.Errorf("The web UI wasn't include in this build. Use `make release` to include it.")
+		log.Printf("The web UI wasn't include in this build. Use `make release` to include it. Serving the API only.")
+	} else {
+		http.HandleFunc("/", serve.Handle)
 	}
-	http.HandleFunc("/", serve.Handle)
 	log.Printf("Listening on :%v...", port)
 	return http.ListenAndServe(":"+port, nil)
 }
@@ -113,6 +122,21 @@ func parseMetadata() ([]jd.Metadata, error) {
 	if *mset {
 		metadata = append(metadata, jd.MULTISET)
 	}
+	if *sortSets {
+		metadata = append(metadata, jd.SORT)
+	}
+	if *ignoreCase {
+		metadata = append(metadata, jd.IGNORE_CASE)
+	}
+	if *ignoreWhitespace {
+		metadata = append(metadata, jd.IGNORE_WHITESPACE)
+	}
+	if *embedded {
+		metadata = append(metadata, jd.EMBEDDED)
+	}
+	if *parallel {
+		metadata = append(metadata, jd.PARALLEL)
+	}
 	if *setkeys != "" {
 		keys := make([]string, 0)
 		ks := strings.Split(*setkeys, ",")
@@ -144,9 +168,20 @@ func printUsageAndExit() {
 		`  -set       Treat arrays as sets.`,
 		`  -mset      Treat arrays as multisets (bags).`,
 		`  -setkeys   Keys to identify set objects`,
+		`  -sort      Sort sets and multisets by value instead of input order.`,
+		`  -ignorecase`,
+		`             Compare strings without regard to letter case.`,
+		`  -ignorewhitespace`,
+		`             Compare strings without regard to leading, trailing or`,
+		`             repeated whitespace.`,
+		`  -embedded  Diff strings holding JSON or YAML objects and arrays by`,
+		`             their content.`,
+		`  -parallel  Diff wide objects and arrays across all CPUs.`,
 		`  -yaml      Read and write YAML instead of JSON.`,
-		`  -port=N    Serve web UI on port N`,
-		`  -f=FORMAT  Produce diff in FORMAT "jd" (default) or "patch" (RFC 6902).`,
+		`  -port=N    Serve web UI and API on port N`,
+		`  -f=FORMAT  Produce diff in FORMAT "jd" (default), "patch" (RFC 6902) or`,
+		`             "human". The human format shows changes within strings line`,
+		`             by line or word by word and cannot be applied as a patch.`,
 		`  -t=FORMATS Translate FILE1 between FORMATS. Supported formats are "jd",`,
 		`             "patch" (RFC 6902), "json" and "yaml". FORMATS are provided`,
 		`             as a pair separated by "2". E.g. "yaml2json" or "jd2patch".`,
@@ -165,67 +200,105 @@ func printUsageAndExit() {
 	os.Exit(2)
 }
 
-func printDiff(a, b string, metadata []jd.Metadata) {
-	var aNode, bNode jd.JsonNode
-	var err error
-	if *yaml {
-		aNode, err = jd.ReadYamlString(a)
-	} else {
-		aNode, err = jd.ReadJsonString(a)
+func printDiff(a, b io.Reader, metadata []jd.Metadata) {
+	aNode := readNode(a)
+	bNode := readNode(b)
+	out := &outputWriter{}
+	var w jd.DiffWriter
+	switch *format {
+	case "", "jd":
+		w = jd.NewJdWriter(out)
+	case "patch":
+		w = jd.NewPatchWriter(out)
+	case "human":
+		w = jd.NewHumanWriter(out)
+	default:
+		errorAndExit("Invalid format: %q", *format)
 	}
-	if err != nil {
-		errorAndExit(err.Error())
+	err := jd.DiffTo(aNode, bNode, w, metadata...)
+	if err == nil {
+		err = w.Close()
 	}
-	if *yaml {
-		bNode, err = jd.ReadYamlString(b)
-	} else {
-		bNode, err = jd.ReadJsonString(b)
+	if err == nil {
+		err = out.Close()
 	}
 	if err != nil {
+		out.Abort()
 		errorAndExit(err.Error())
 	}
-	diff := aNode.Diff(bNode, metadata...)
-	var str string
-	switch *format {
-	case "", "jd":
-		str = diff.Render()
-	case "patch":
-		str, err = diff.RenderPatch()
-		if err != nil {
-			errorAndExit(err.Error())
-		}
-	default:
-		errorAndExit("Invalid format: %q", *format)
+	if out.written == 0 {
+		os.Exit(0)
 	}
-	if *output == "" {
-		if str == "" {
-			os.Exit(0)
-		}
-		fmt.Print(str)
-		os.Exit(1)
-	} else {
-		if str == "" {
-			os.Exit(0)
+	os.Exit(1)
+}
+
+// outputWriter writes to the -o file or stdout. The -o file is written
+// to a temporary file next to it which replaces it on Close, so a failed
+// run leaves the -o file untouched. Nothing is created until there is
+// something to write.
+type outputWriter struct {
+	w       *bufio.Writer
+	f       *os.File
+	written int
+}
+
+func (o *outputWriter) Write(p []byte) (int, error) {
+	if o.w == nil {
+		if *output == "" {
+			o.w = bufio.NewWriter(os.Stdout)
+		} else {
+			f, err := ioutil.TempFile(filepath.Dir(*output), "."+filepath.Base(*output)+".*")
+			if err != nil {
+				return 0, err
+			}
+			o.f = f
+			o.w = bufio.NewWriter(f)
 		}
-		ioutil.WriteFile(*output, []byte(str), 0644)
-		os.Exit(1)
 	}
+	n, err := o.w.Write(p)
+	o.written += n
+	return n, err
 }
 
-func printPatch(p, a string, metadata []jd.Metadata) {
-	diff, err := jd.ReadDiffString(p)
-	if err != nil {
-		errorAndExit(err.Error())
+func (o *outputWriter) Close() error {
+	if o.w == nil {
+		return nil
 	}
-	var aNode jd.JsonNode
-	if *yaml {
-		aNode, err = jd.ReadYamlString(a)
-	} else {
-		aNode, err = jd.ReadJsonString(a)
+	if err := o.w.Flush(); err != nil {
+		return err
+	}
+	if o.f == nil {
+		return nil
+	}
+	if err := o.f.Chmod(0644); err != nil {
+		return err
 	}
+	if err := o.f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(o.f.Name(), *output)
+}
+
+// Abort ends a failed run. Output already written to stdout is flushed
+// and the temporary -o file is removed.
+func (o *outputWriter) Abort() {
+	if o.w == nil {
+		return
+	}
+	if o.f == nil {
+		o.w.Flush()
+		return
+	}
+	o.f.Close()
+	os.Remove(o.f.Name())
+}
+
+func printPatch(p, a io.Reader, metadata []jd.Metadata) {
+	diff, err := jd.ReadDiffString(readAll(p))
 	if err != nil {
 		errorAndExit(err.Error())
 	}
+	aNode := readNode(a)
 	bNode, err := aNode.Patch(diff)
 	if err != nil {
 		errorAndExit(err.Error())
@@ -251,11 +324,11 @@ func printPatch(p, a string, metadata []jd.Metadata) {
 	}
 }
 
-func printTranslation(a string, metadata []jd.Metadata) {
+func printTranslation(a io.Reader, metadata []jd.Metadata) {
 	var out string
 	switch *translate {
 	case "jd2patch":
-		diff, err := jd.ReadDiffString(a)
+		diff, err := jd.ReadDiffString(readAll(a))
 		if err != nil {
 			errorAndExit(err.Error())
 		}
@@ -264,19 +337,19 @@ func printTranslation(a string, metadata []jd.Metadata) {
 			errorAndExit(err.Error())
 		}
 	case "patch2jd":
-		patch, err := jd.ReadPatchString(a)
+		patch, err := jd.ReadPatchString(readAll(a))
 		if err != nil {
 			errorAndExit(err.Error())
 		}
 		out = patch.Render()
 	case "json2yaml":
-		node, err := jd.ReadJsonString(a)
+		node, err := jd.ReadJson(a)
 		if err != nil {
 			errorAndExit(err.Error())
 		}
 		out = node.Yaml()
 	case "yaml2json":
-		node, err := jd.ReadYamlString(a)
+		node, err := jd.ReadYaml(a)
 		if err != nil {
 			errorAndExit(err.Error())
 		}
@@ -297,17 +370,16 @@ func errorAndExit(msg string, args ...interface{}) {
 	os.Exit(2)
 }
 
-func readFile(filename string) string {
-	bytes, err := ioutil.ReadFile(filename)
+func openFile(filename string) io.Reader {
+	f, err := os.Open(filename)
 	if err != nil {
 		log.Printf(err.Error())
 		os.Exit(2)
 	}
-	return string(bytes)
+	return bufio.NewReader(f)
 }
 
-func readStdin() string {
-	r := bufio.NewReader(os.Stdin)
+func readAll(r io.Reader) string {
 	bytes, err := ioutil.ReadAll(r)
 	if err != nil {
 		log.Printf(err.Error())
@@ -315,3 +387,17 @@ func readStdin() string {
 	}
 	return string(bytes)
 }
+
+func readNode(r io.Reader) jd.JsonNode {
+	var n jd.JsonNode
+	var err error
+	if *yaml {
+		n, err = jd.ReadYaml(r)
+	} else {
+		n, err = jd.ReadJson(r)
+	}
+	if err != nil {
+		errorAndExit(err.Error())
+	}
+	return n
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs jd with the newline separated JD_TEST_ARGS when set so
+// that tests can check the exit code and files of a run.
+func TestMain(m *testing.M) {
+	if args := os.Getenv("JD_TEST_ARGS"); args != "" {
+		os.Args = append([]string{"jd"}, strings.Split(args, "\n")...)
+		main()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func runJd(t *testing.T, args ...string) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "JD_TEST_ARGS="+strings.Join(args, "\n"))
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("running jd: %v", err)
+	}
+	return 0
+}
+
+func TestOutputFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		a        string
+		b        string
+		wantCode int
+		wantOut  string
+	}{{
+		name:     "diff replaces output file",
+		a:        `{"a":1,"b":[1]}`,
+		b:        `{"a":2,"b":[2]}`,
+		wantCode: 1,
+		wantOut:  "@ [\"a\"]\n- 1\n+ 2\n@ [\"b\",0]\n- 1\n+ 2\n",
+	}, {
+		name:     "failed diff leaves output file untouched",
+		args:     []string{"-set", "-f", "patch"},
+		a:        `{"a":1,"b":[1]}`,
+		b:        `{"a":2,"b":[2]}`,
+		wantCode: 2,
+		wantOut:  "original\n",
+	}}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "jd")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			a := filepath.Join(dir, "a.json")
+			b := filepath.Join(dir, "b.json")
+			out := filepath.Join(dir, "out")
+			for name, content := range map[string]string{
+				a:   c.a,
+				b:   c.b,
+				out: "original\n",
+			} {
+				if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			args := append(append([]string{}, c.args...), "-o", out, a, b)
+			if code := runJd(t, args...); code != c.wantCode {
+				t.Errorf("wanted exit code %v. Got %v", c.wantCode, code)
+			}
+			got, err := ioutil.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != c.wantOut {
+				t.Errorf("wanted output file %q. Got %q", c.wantOut, string(got))
+			}
+			files, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 3 {
+				t.Errorf("wanted only a, b and out in %v. Got %v files", dir, len(files))
+			}
+		})
+	}
+}
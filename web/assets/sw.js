@@ -0,0 +1,51 @@
+// Service worker which keeps the jd web UI available offline. Files are
+// served from the cache and refreshed in the background, so a new
+// release is picked up on the next visit. The API is never cached.
+
+const cacheName = "jd";
+const files = [
+  "./",
+  "index.html",
+  "wasm_exec.js",
+  "jd.wasm",
+  "manifest.json",
+  "icon.svg",
+];
+
+self.addEventListener("install", (event) => {
+  event.waitUntil(
+    caches.open(cacheName).then((cache) => cache.addAll(files))
+  );
+  self.skipWaiting();
+});
+
+self.addEventListener("activate", (event) => {
+  event.waitUntil(self.clients.claim());
+});
+
+self.addEventListener("fetch", (event) => {
+  const url = new URL(event.request.url);
+  if (event.request.method !== "GET" ||
+      url.origin !== self.location.origin ||
+      url.pathname.startsWith("/api/")) {
+    return;
+  }
+  event.respondWith(
+    caches.open(cacheName).then((cache) =>
+      cache.match(event.request, {ignoreSearch: true}).then((cached) => {
+        const fetched = fetch(event.request).then((response) => {
+          if (response.ok) {
+            cache.put(event.request, response.clone());
+          }
+          return response;
+        });
+        if (cached) {
+          // Refresh the cache without waiting on the network.
+          event.waitUntil(fetched.catch(() => {}));
+          return cached;
+        }
+        return fetched;
+      })
+    )
+  );
+});
@@ -10,6 +10,9 @@ var files = []string{
 	"wasm_exec.js",
 	"index.html",
 	"jd.wasm",
+	"sw.js",
+	"manifest.json",
+	"icon.svg",
 }
 
 func main() {
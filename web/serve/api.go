@@ -0,0 +1,240 @@
+package serve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	jd "github.com/josephburnett/jd/lib"
+)
+
+// maxRequestBytes limits the size of API request bodies.
+const maxRequestBytes = 64 << 20
+
+// ApiRequest holds the documents and options of an API call. Options
+// mirror the command line flags.
+type ApiRequest struct {
+	// A is the first document, the document to patch or the input to
+	// translate.
+	A string `json:"a"`
+	// B is the second document to diff.
+	B string `json:"b"`
+	// Diff is the diff to apply, in Format.
+	Diff string `json:"diff"`
+	// Format is the diff format: "jd" (default), "patch" or "human".
+	Format string `json:"format"`
+	// Translate is the translation, e.g. "jd2patch" or "yaml2json".
+	Translate string   `json:"translate"`
+	Set       bool     `json:"set"`
+	Mset      bool     `json:"mset"`
+	Setkeys   []string `json:"setkeys"`
+	Yaml      bool     `json:"yaml"`
+}
+
+// ApiResponse is the result of an API call. Error is set instead of
+// Output when the call fails.
+type ApiResponse struct {
+	Output string `json:"output"`
+	// Different is set by diff when the documents differ.
+	Different bool   `json:"different,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// RegisterApi adds the JSON API endpoints to mux. They don't depend on
+// the web UI being included in the build.
+func RegisterApi(mux *http.ServeMux) {
+	mux.HandleFunc("/api/diff", apiHandler(apiDiff))
+	mux.HandleFunc("/api/patch", apiHandler(apiPatch))
+	mux.HandleFunc("/api/translate", apiHandler(apiTranslate))
+}
+
+// apiError is an error with the HTTP status to report it with.
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(err error) error {
+	return &apiError{http.StatusBadRequest, err}
+}
+
+func apiHandler(fn func(ApiRequest) (ApiResponse, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeApiResponse(w, http.StatusMethodNotAllowed, ApiResponse{
+				Error: "Method not allowed. Use POST.",
+			})
+			return
+		}
+		var req ApiRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeApiResponse(w, http.StatusBadRequest, ApiResponse{
+				Error: fmt.Sprintf("Invalid request. %v", err),
+			})
+			return
+		}
+		res, err := fn(req)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if e, ok := err.(*apiError); ok {
+				status = e.status
+			}
+			writeApiResponse(w, status, ApiResponse{
+				Error: err.Error(),
+			})
+			return
+		}
+		writeApiResponse(w, http.StatusOK, res)
+	}
+}
+
+func writeApiResponse(w http.ResponseWriter, status int, res ApiResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (req ApiRequest) metadata() ([]jd.Metadata, error) {
+	metadata := make([]jd.Metadata, 0)
+	if req.Set {
+		metadata = append(metadata, jd.SET)
+	}
+	if req.Mset {
+		metadata = append(metadata, jd.MULTISET)
+	}
+	if len(req.Setkeys) > 0 {
+		keys := make([]string, 0, len(req.Setkeys))
+		for _, k := range req.Setkeys {
+			trimmed := strings.TrimSpace(k)
+			if trimmed == "" {
+				return nil, badRequest(fmt.Errorf("Invalid set key: %v", k))
+			}
+			keys = append(keys, trimmed)
+		}
+		metadata = append(metadata, jd.Setkeys(keys...))
+	}
+	return metadata, nil
+}
+
+func (req ApiRequest) readNode(name, s string) (jd.JsonNode, error) {
+	var n jd.JsonNode
+	var err error
+	if req.Yaml {
+		n, err = jd.ReadYamlString(s)
+	} else {
+		n, err = jd.ReadJsonString(s)
+	}
+	if err != nil {
+		return nil, badRequest(fmt.Errorf("Invalid %v. %v", name, err))
+	}
+	return n, nil
+}
+
+func apiDiff(req ApiRequest) (ApiResponse, error) {
+	metadata, err := req.metadata()
+	if err != nil {
+		return ApiResponse{}, err
+	}
+	a, err := req.readNode("a", req.A)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+	b, err := req.readNode("b", req.B)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+	diff := a.Diff(b, metadata...)
+	res := ApiResponse{
+		Different: len(diff) > 0,
+	}
+	switch req.Format {
+	case "", "jd":
+		res.Output = diff.Render()
+	case "patch":
+		res.Output, err = diff.RenderPatch()
+		if err != nil {
+			return ApiResponse{}, &apiError{http.StatusUnprocessableEntity, err}
+		}
+	case "human":
+		res.Output = diff.RenderHuman()
+	default:
+		return ApiResponse{}, badRequest(fmt.Errorf("Invalid format: %q", req.Format))
+	}
+	return res, nil
+}
+
+func apiPatch(req ApiRequest) (ApiResponse, error) {
+	metadata, err := req.metadata()
+	if err != nil {
+		return ApiResponse{}, err
+	}
+	var diff jd.Diff
+	switch req.Format {
+	case "", "jd":
+		diff, err = jd.ReadDiffString(req.Diff)
+	case "patch":
+		diff, err = jd.ReadPatchString(req.Diff)
+	default:
+		return ApiResponse{}, badRequest(fmt.Errorf("Invalid format: %q", req.Format))
+	}
+	if err != nil {
+		return ApiResponse{}, badRequest(fmt.Errorf("Invalid diff. %v", err))
+	}
+	a, err := req.readNode("a", req.A)
+	if err != nil {
+		return ApiResponse{}, err
+	}
+	b, err := a.Patch(diff)
+	if err != nil {
+		// The diff doesn't apply to the document.
+		return ApiResponse{}, &apiError{http.StatusUnprocessableEntity, err}
+	}
+	if req.Yaml {
+		return ApiResponse{Output: b.Yaml(metadata...)}, nil
+	}
+	return ApiResponse{Output: b.Json(metadata...)}, nil
+}
+
+func apiTranslate(req ApiRequest) (ApiResponse, error) {
+	var out string
+	switch req.Translate {
+	case "jd2patch":
+		diff, err := jd.ReadDiffString(req.A)
+		if err != nil {
+			return ApiResponse{}, badRequest(err)
+		}
+		out, err = diff.RenderPatch()
+		if err != nil {
+			return ApiResponse{}, &apiError{http.StatusUnprocessableEntity, err}
+		}
+	case "patch2jd":
+		patch, err := jd.ReadPatchString(req.A)
+		if err != nil {
+			return ApiResponse{}, badRequest(err)
+		}
+		out = patch.Render()
+	case "json2yaml":
+		node, err := jd.ReadJsonString(req.A)
+		if err != nil {
+			return ApiResponse{}, badRequest(err)
+		}
+		out = node.Yaml()
+	case "yaml2json":
+		node, err := jd.ReadYamlString(req.A)
+		if err != nil {
+			return ApiResponse{}, badRequest(err)
+		}
+		out = node.Json()
+	default:
+		return ApiResponse{}, badRequest(fmt.Errorf("Unsupported translation: %q", req.Translate))
+	}
+	return ApiResponse{Output: out}, nil
+}
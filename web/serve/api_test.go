@@ -0,0 +1,182 @@
+package serve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestApi(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantOutput string
+		wantDiff   bool
+		wantError  string
+	}{{
+		name:       "diff jd",
+		path:       "/api/diff",
+		body:       `{"a":"{\"a\":1}","b":"{\"a\":2}"}`,
+		wantStatus: http.StatusOK,
+		wantOutput: "@ [\"a\"]\n- 1\n+ 2\n",
+		wantDiff:   true,
+	}, {
+		name:       "diff equal",
+		path:       "/api/diff",
+		body:       `{"a":"[1,2]","b":"[1,2]"}`,
+		wantStatus: http.StatusOK,
+		wantOutput: "",
+	}, {
+		name:       "diff patch",
+		path:       "/api/diff",
+		body:       `{"a":"{\"a\":1}","b":"{\"a\":2}","format":"patch"}`,
+		wantStatus: http.StatusOK,
+		wantOutput: `[{"op":"test","path":"/a","value":1},{"op":"remove","path":"/a","value":1},{"op":"add","path":"/a","value":2}]`,
+		wantDiff:   true,
+	}, {
+		name:       "diff set",
+		path:       "/api/diff",
+		body:       `{"a":"[1,2]","b":"[2,1]","set":true}`,
+		wantStatus: http.StatusOK,
+		wantOutput: "",
+	}, {
+		name:       "diff setkeys",
+		path:       "/api/diff",
+		body:       `{"a":"[{\"id\":1,\"x\":1}]","b":"[{\"id\":1,\"x\":2}]","set":true,"setkeys":["id"]}`,
+		wantStatus: http.StatusOK,
+		wantOutput: "@ [[\"set\",\"setkeys=id\"],{\"id\":1},\"x\"]\n- 1\n+ 2\n",
+		wantDiff:   true,
+	}, {
+		name:       "diff yaml",
+		path:       "/api/diff",
+		body:       `{"a":"a: 1","b":"a: 2","yaml":true}`,
+		wantStatus: http.StatusOK,
+		wantOutput: "@ [\"a\"]\n- 1\n+ 2\n",
+		wantDiff:   true,
+	}, {
+		name:       "diff invalid document",
+		path:       "/api/diff",
+		body:       `{"a":"{","b":"{}"}`,
+		wantStatus: http.StatusBadRequest,
+		wantError:  "Invalid a.",
+	}, {
+		name:       "diff invalid format",
+		path:       "/api/diff",
+		body:       `{"a":"1","b":"2","format":"xml"}`,
+		wantStatus: http.StatusBadRequest,
+		wantError:  "Invalid format",
+	}, {
+		name:       "diff invalid setkeys",
+		path:       "/api/diff",
+		body:       `{"a":"1","b":"2","setkeys":["id",""]}`,
+		wantStatus: http.StatusBadRequest,
+		wantError:  "Invalid set key",
+	}, {
+		name:       "patch jd",
+		path:       "/api/patch",
+		body:       `{"a":"{\"a\":1}","diff":"@ [\"a\"]\n- 1\n+ 2\n"}`,
+		wantStatus: http.StatusOK,
+		wantOutput: `{"a":2}`,
+	}, {
+		name:       "patch rfc 6902",
+		path:       "/api/patch",
+		body:       `{"a":"{\"a\":1}","diff":"[{\"op\":\"test\",\"path\":\"/a\",\"value\":1},{\"op\":\"remove\",\"path\":\"/a\",\"value\":1},{\"op\":\"add\",\"path\":\"/a\",\"value\":2}]","format":"patch"}`,
+		wantStatus: http.StatusOK,
+		wantOutput: `{"a":2}`,
+	}, {
+		name:       "patch yaml",
+		path:       "/api/patch",
+		body:       `{"a":"a: 1","diff":"@ [\"a\"]\n- 1\n+ 2\n","yaml":true}`,
+		wantStatus: http.StatusOK,
+		wantOutput: "a: 2\n",
+	}, {
+		name:       "patch conflict",
+		path:       "/api/patch",
+		body:       `{"a":"{\"a\":3}","diff":"@ [\"a\"]\n- 1\n+ 2\n"}`,
+		wantStatus: http.StatusUnprocessableEntity,
+		wantError:  "Found 3",
+	}, {
+		name:       "patch invalid diff",
+		path:       "/api/patch",
+		body:       `{"a":"{}","diff":"nope"}`,
+		wantStatus: http.StatusBadRequest,
+		wantError:  "Invalid diff.",
+	}, {
+		name:       "translate jd2patch",
+		path:       "/api/translate",
+		body:       `{"a":"@ [\"a\"]\n+ 1\n","translate":"jd2patch"}`,
+		wantStatus: http.StatusOK,
+		wantOutput: `[{"op":"add","path":"/a","value":1}]`,
+	}, {
+		name:       "translate yaml2json",
+		path:       "/api/translate",
+		body:       `{"a":"a: 1","translate":"yaml2json"}`,
+		wantStatus: http.StatusOK,
+		wantOutput: `{"a":1}`,
+	}, {
+		name:       "translate unsupported",
+		path:       "/api/translate",
+		body:       `{"a":"a: 1","translate":"yaml2xml"}`,
+		wantStatus: http.StatusBadRequest,
+		wantError:  "Unsupported translation",
+	}, {
+		name:       "unknown field",
+		path:       "/api/diff",
+		body:       `{"a":"1","c":"2"}`,
+		wantStatus: http.StatusBadRequest,
+		wantError:  "Invalid request.",
+	}, {
+		name:       "malformed request",
+		path:       "/api/diff",
+		body:       `{"a":`,
+		wantStatus: http.StatusBadRequest,
+		wantError:  "Invalid request.",
+	}, {
+		name:       "wrong method",
+		method:     http.MethodGet,
+		path:       "/api/diff",
+		wantStatus: http.StatusMethodNotAllowed,
+		wantError:  "Method not allowed.",
+	}}
+
+	mux := http.NewServeMux()
+	RegisterApi(mux)
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			method := c.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, c.path, strings.NewReader(c.body))
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code != c.wantStatus {
+				t.Errorf("Wanted status %v. Got %v: %v", c.wantStatus, rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Wanted application/json. Got %q", got)
+			}
+			var res ApiResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Invalid response %q: %v", rec.Body.String(), err)
+			}
+			if res.Output != c.wantOutput {
+				t.Errorf("Wanted output %q. Got %q", c.wantOutput, res.Output)
+			}
+			if res.Different != c.wantDiff {
+				t.Errorf("Wanted different %v. Got %v", c.wantDiff, res.Different)
+			}
+			if c.wantError == "" && res.Error != "" {
+				t.Errorf("Wanted no error. Got %q", res.Error)
+			}
+			if !strings.Contains(res.Error, c.wantError) {
+				t.Errorf("Wanted error containing %q. Got %q", c.wantError, res.Error)
+			}
+		})
+	}
+}
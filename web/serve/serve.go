@@ -5,7 +5,9 @@ package serve
 import (
 	"encoding/base64"
 	"fmt"
+	"mime"
 	"net/http"
+	"path"
 )
 
 func init() {
@@ -27,6 +29,10 @@ func handle(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "error base64 decoding", http.StatusInternalServerError)
 		return
 	}
+	// Service workers and WebAssembly streaming need accurate types.
+	if t := mime.TypeByExtension(path.Ext(f)); t != "" {
+		w.Header().Set("Content-Type", t)
+	}
 	w.WriteHeader(http.StatusOK)
 	w.Write(b)
 }
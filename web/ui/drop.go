@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+// watchDrop reads a file dropped onto the textarea with id into it.
+// Files are read locally and never leave the browser.
+func (a *app) watchDrop(id string) error {
+	element := a.getElementById(id)
+	if element.IsNull() {
+		return fmt.Errorf("id %v not found", id)
+	}
+	dragover := func(_ js.Value, args []js.Value) interface{} {
+		// Allow dropping instead of opening the file.
+		args[0].Call("preventDefault")
+		return nil
+	}
+	drop := func(_ js.Value, args []js.Value) interface{} {
+		defer a.catchPanic()
+		event := args[0]
+		event.Call("preventDefault")
+		a.mux.Lock()
+		input := a.isInput(id)
+		a.mux.Unlock()
+		files := event.Get("dataTransfer").Get("files")
+		if !input || files.Length() == 0 {
+			return nil
+		}
+		var loaded js.Func
+		loaded = js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+			defer a.catchPanic()
+			loaded.Release()
+			a.setTextarea(id, args[0].String())
+			a.changeCh <- struct{}{}
+			return nil
+		})
+		files.Index(0).Call("text").Call("then", loaded)
+		return nil
+	}
+	element.Call("addEventListener", "dragover", js.FuncOf(dragover))
+	element.Call("addEventListener", "drop", js.FuncOf(drop))
+	return nil
+}
+
+// isInput reports whether the textarea with id is an input in the
+// current mode, as opposed to showing output.
+func (a *app) isInput(id string) bool {
+	switch id {
+	case aJsonId:
+		return true
+	case bJsonId:
+		return a.mode == modeDiffId
+	case diffId:
+		return a.mode == modePatchId
+	}
+	return false
+}
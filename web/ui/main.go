@@ -1,8 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"syscall/js"
 
@@ -22,6 +24,7 @@ const (
 	diffErrorId             = "diff-error"
 	modeDiffId              = "mode-diff"
 	modePatchId             = "mode-patch"
+	modeTranslateId         = "mode-translate"
 	formatJsonId            = "format-json"
 	formatYamlId            = "format-yaml"
 	diffFormatJdId          = "diff-format-jd"
@@ -29,6 +32,18 @@ const (
 	arrayListId             = "array-list"
 	arraySetId              = "array-set"
 	arrayMsetId             = "array-mset"
+	setkeysId               = "setkeys"
+	setkeysErrorId          = "setkeys-error"
+	ignoreId                = "ignore"
+	ignoreErrorId           = "ignore-error"
+	patchPrettyId           = "patch-pretty"
+	outputFormatSameId      = "output-format-same"
+	outputFormatJsonId      = "output-format-json"
+	outputFormatYamlId      = "output-format-yaml"
+	translateJsonYamlId     = "translate-json2yaml"
+	translateYamlJsonId     = "translate-yaml2json"
+	translateJdPatchId      = "translate-jd2patch"
+	translatePatchJdId      = "translate-patch2jd"
 	focusStyle              = "border:solid 3px #080"
 	unfocusStyle            = "border:solid 3px #ccc"
 	halfWidthStyle          = "width:97%"
@@ -55,21 +70,33 @@ type app struct {
 	format     string
 	diffFormat string
 	array      string
+	fragment   string
+	showView   bool
+	view       *renderedView
+	// prettyPatch puts each JSON Patch op on its own line.
+	prettyPatch bool
+	// outputFormat is the format of b in patch mode.
+	outputFormat string
+	translation  string
 }
 
 func newApp() (*app, error) {
 	a := &app{
-		changeCh:   make(chan struct{}, 10),
-		doc:        js.Global().Get("document"),
-		mode:       modeDiffId,
-		format:     formatJsonId,
-		diffFormat: diffFormatJdId,
-		array:      arrayListId,
+		changeCh:     make(chan struct{}, 10),
+		doc:          js.Global().Get("document"),
+		mode:         modeDiffId,
+		format:       formatJsonId,
+		diffFormat:   diffFormatJdId,
+		array:        arrayListId,
+		outputFormat: outputFormatSameId,
+		translation:  translateJsonYamlId,
 	}
 	for _, id := range []string{
 		aJsonId,
 		bJsonId,
 		diffId,
+		setkeysId,
+		ignoreId,
 	} {
 		err := a.watchInput(id)
 		if err != nil {
@@ -79,6 +106,7 @@ func newApp() (*app, error) {
 	for _, id := range []string{
 		modeDiffId,
 		modePatchId,
+		modeTranslateId,
 	} {
 		err := a.watchChange(id, &a.mode)
 		if err != nil {
@@ -113,6 +141,50 @@ func newApp() (*app, error) {
 			return nil, err
 		}
 	}
+	for _, id := range []string{
+		outputFormatSameId,
+		outputFormatJsonId,
+		outputFormatYamlId,
+	} {
+		err := a.watchChange(id, &a.outputFormat)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range []string{
+		translateJsonYamlId,
+		translateYamlJsonId,
+		translateJdPatchId,
+		translatePatchJdId,
+	} {
+		err := a.watchChange(id, &a.translation)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := a.watchCheckbox(patchPrettyId, &a.prettyPatch); err != nil {
+		return nil, err
+	}
+	for _, id := range []string{
+		aJsonId,
+		bJsonId,
+		diffId,
+	} {
+		err := a.watchDrop(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := a.watchCheckbox(viewShowId, &a.showView); err != nil {
+		return nil, err
+	}
+	if err := a.watchDiffClick(); err != nil {
+		return nil, err
+	}
+	if err := a.watchFragment(); err != nil {
+		return nil, err
+	}
+	a.restoreFragment()
 	go a.handleChange()
 	a.changeCh <- struct{}{}
 	return a, nil
@@ -149,6 +221,23 @@ func (a *app) watchChange(id string, s *string) error {
 	return nil
 }
 
+func (a *app) watchCheckbox(id string, b *bool) error {
+	element := a.getElementById(id)
+	if element.IsNull() {
+		return fmt.Errorf("id %v not found", id)
+	}
+	listener := func(_ js.Value, _ []js.Value) interface{} {
+		defer a.catchPanic()
+		a.mux.Lock()
+		defer a.mux.Unlock()
+		*b = element.Get("checked").Bool()
+		a.changeCh <- struct{}{}
+		return nil
+	}
+	element.Call("addEventListener", "change", js.FuncOf(listener))
+	return nil
+}
+
 func (a *app) handleChange() {
 	defer a.catchPanic()
 	for {
@@ -174,7 +263,12 @@ func (a *app) reconcile() {
 		a.printDiff()
 	case modePatchId:
 		a.printPatch()
+	case modeTranslateId:
+		a.printTranslation()
 	}
+
+	a.setLabel(copyLinkStatusId, "")
+	a.updateFragment()
 }
 
 func (a *app) setDerived() {
@@ -203,6 +297,12 @@ func (a *app) setPlaceholder() {
 
 func (a *app) setCommandLabel() {
 	command := "jd"
+	if a.mode == modeTranslateId {
+		translation := strings.TrimPrefix(a.translation, "translate-")
+		input := strings.Split(translation, "2")[0]
+		a.setLabel(commandId, fmt.Sprintf("jd -t %v a.%v", translation, input))
+		return
+	}
 	switch a.mode {
 	case modePatchId:
 		command += " -p"
@@ -224,6 +324,9 @@ func (a *app) setCommandLabel() {
 		command += " -mset"
 	default:
 	}
+	if keys, err := a.getSetkeys(); err == nil && len(keys) > 0 && a.array != arrayListId {
+		command += " -setkeys " + strings.Join(keys, ",")
+	}
 	switch a.mode {
 	case modeDiffId:
 		command += " a.json b.json"
@@ -237,6 +340,12 @@ func (a *app) setCommandLabel() {
 func (a *app) setInputLabels() {
 	aLabel := a.getElementById(aLabelId)
 	bLabel := a.getElementById(bLabelId)
+	if a.mode == modeTranslateId {
+		formats := strings.Split(strings.TrimPrefix(a.translation, "translate-"), "2")
+		aLabel.Set("innerHTML", "a."+formats[0])
+		bLabel.Set("innerHTML", "b."+formats[1])
+		return
+	}
 	if a.format == formatJsonId {
 		aLabel.Set("innerHTML", "a.json")
 		bLabel.Set("innerHTML", "b.json")
@@ -263,6 +372,12 @@ func (a *app) setInputsEnabled() {
 		bJson.Set("style", unfocusStyle+";"+halfWidthStyle)
 		diffText.Set("readonly", js.ValueOf(false))
 		diffText.Set("style", focusStyle+";"+fullWidthStyle)
+	case modeTranslateId:
+		aJson.Set("style", focusStyle+";"+halfWidthStyle)
+		bJson.Set("readonly", js.ValueOf(true))
+		bJson.Set("style", unfocusStyle+";"+halfWidthStyle)
+		diffText.Set("readonly", js.ValueOf(true))
+		diffText.Set("style", unfocusStyle+";"+fullWidthStyle)
 	default:
 	}
 	buttons := []string{
@@ -279,6 +394,28 @@ func (a *app) setInputsEnabled() {
 			e.Set("disabled", js.ValueOf(true))
 		}
 	}
+	a.getElementById(setkeysId).Set("disabled", a.array == arrayListId)
+	// Ignored paths only filter computed diffs. A given diff is
+	// applied whole, like jd -p.
+	a.getElementById(ignoreId).Set("disabled", a.mode != modeDiffId)
+	if a.mode != modeDiffId {
+		a.setLabel(ignoreErrorId, "")
+	}
+	for _, id := range []string{
+		outputFormatSameId,
+		outputFormatJsonId,
+		outputFormatYamlId,
+	} {
+		a.getElementById(id).Set("disabled", a.mode != modePatchId)
+	}
+	for _, id := range []string{
+		translateJsonYamlId,
+		translateYamlJsonId,
+		translateJdPatchId,
+		translatePatchJdId,
+	} {
+		a.getElementById(id).Set("disabled", a.mode != modeTranslateId)
+	}
 }
 
 func (a *app) getMetadata() []jd.Metadata {
@@ -290,9 +427,103 @@ func (a *app) getMetadata() []jd.Metadata {
 		metadata = append(metadata, jd.MULTISET)
 	default:
 	}
+	keys, err := a.getSetkeys()
+	if err != nil {
+		a.setLabel(setkeysErrorId, err.Error())
+		return metadata
+	}
+	a.setLabel(setkeysErrorId, "")
+	if len(keys) > 0 && a.array != arrayListId {
+		metadata = append(metadata, jd.Setkeys(keys...))
+	}
 	return metadata
 }
 
+// getSetkeys reads the comma separated keys which identify objects in
+// sets, like the -setkeys flag.
+func (a *app) getSetkeys() ([]string, error) {
+	keys := []string{}
+	value := a.getElementById(setkeysId).Get("value").String()
+	if strings.TrimSpace(value) == "" {
+		return keys, nil
+	}
+	for _, k := range strings.Split(value, ",") {
+		trimmed := strings.TrimSpace(k)
+		if trimmed == "" {
+			return nil, fmt.Errorf("Invalid set key: %v", k)
+		}
+		keys = append(keys, trimmed)
+	}
+	return keys, nil
+}
+
+// getIgnored reads the paths to leave out of diffs, one per line. Paths
+// are in the format of diff headers, e.g. ["foo",0], or JSON Pointers,
+// e.g. /foo/0.
+func (a *app) getIgnored() ([]jd.Path, error) {
+	paths := []jd.Path{}
+	for _, line := range strings.Split(a.getElementById(ignoreId).Get("value").String(), "\n") {
+		line = strings.TrimSpace(line)
+		var p jd.Path
+		var err error
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "/"):
+			p, err = jd.FromPointer(line)
+		default:
+			p, err = jd.ParsePath(line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid path %v: %v", line, err)
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// filterIgnored drops the elements of diff under ignored paths.
+func (a *app) filterIgnored(diff jd.Diff) jd.Diff {
+	ignored, err := a.getIgnored()
+	if err != nil {
+		a.setLabel(ignoreErrorId, err.Error())
+		return diff
+	}
+	a.setLabel(ignoreErrorId, "")
+	return diff.Filter(func(e jd.DiffElement) bool {
+		for _, p := range ignored {
+			if e.Path.HasPrefix(p) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// renderPatch renders diff as JSON Patch. An empty patch is rendered
+// as nothing.
+func (a *app) renderPatch(diff jd.Diff) (string, error) {
+	out, err := diff.RenderPatch()
+	if err != nil {
+		return "", err
+	}
+	if out == "[]" {
+		return "", nil
+	}
+	if !a.prettyPatch {
+		return out, nil
+	}
+	ops := []json.RawMessage{}
+	if err := json.Unmarshal([]byte(out), &ops); err != nil {
+		return "", err
+	}
+	lines := make([]string, len(ops))
+	for i, op := range ops {
+		lines[i] = string(op)
+	}
+	return "[\n  " + strings.Join(lines, ",\n  ") + "\n]\n", nil
+}
+
 func (a *app) parseAndTranslate(id string) (jd.JsonNode, error) {
 	value := a.getElementById(id)
 	nodeJson, errJson := jd.ReadJsonString(value.Get("value").String())
@@ -326,13 +557,10 @@ func (a *app) parseAndTranslateDiff() (jd.Diff, error) {
 	diffPatch, errPatch := jd.ReadPatchString(diffText.Get("value").String())
 	// Translate jd to patch.
 	if a.diffFormat == diffFormatPatchId && errPatch != nil && errJd == nil {
-		patchString, err := diffJd.RenderPatch()
+		patchString, err := a.renderPatch(diffJd)
 		if err != nil {
 			return nil, err
 		}
-		if patchString == "[]" {
-			patchString = ""
-		}
 		a.setTextarea(diffId, patchString)
 	}
 	// Translate patch to jd.
@@ -375,22 +603,21 @@ func (a *app) printDiff() {
 	}
 	if fail {
 		a.setTextarea(diffId, "")
+		a.printView(nil, nil, nil)
 		return
 	}
 	// Print diff
-	diff := aNode.Diff(bNode, metadata...)
+	diff := a.filterIgnored(aNode.Diff(bNode, metadata...))
+	a.printView(aNode, bNode, diff)
 	var out string
 	switch a.diffFormat {
 	case diffFormatJdId:
 		out = diff.Render()
 	case diffFormatPatchId:
-		out, err = diff.RenderPatch()
+		out, err = a.renderPatch(diff)
 		if err != nil {
 			a.setLabel(diffErrorId, err.Error())
 		}
-		if out == "[]" {
-			out = ""
-		}
 	}
 	a.setTextarea(diffId, out)
 }
@@ -422,6 +649,7 @@ func (a *app) printPatch() {
 	}
 	if fail {
 		a.setTextarea(bJsonId, "")
+		a.printView(nil, nil, nil)
 		return
 	}
 	// Print patch
@@ -434,10 +662,19 @@ func (a *app) printPatch() {
 	}
 	if fail {
 		a.setTextarea(bJsonId, "")
+		a.printView(nil, nil, nil)
 		return
 	}
+	a.printView(aNode, bNode, diff)
+	outputFormat := a.outputFormat
+	if outputFormat == outputFormatSameId {
+		outputFormat = map[string]string{
+			formatJsonId: outputFormatJsonId,
+			formatYamlId: outputFormatYamlId,
+		}[a.format]
+	}
 	var out string
-	if a.format == formatJsonId {
+	if outputFormat == outputFormatJsonId {
 		out = bNode.Json(metadata...)
 	} else {
 		out = bNode.Yaml(metadata...)
@@ -445,12 +682,57 @@ func (a *app) printPatch() {
 	a.setTextarea(bJsonId, out)
 }
 
+func (a *app) printTranslation() {
+	a.printView(nil, nil, nil)
+	a.setLabel(bErrorId, "")
+	a.setLabel(diffErrorId, "")
+	a.setTextarea(diffId, "")
+	in := a.getElementById(aJsonId).Get("value").String()
+	var out string
+	var err error
+	switch a.translation {
+	case translateJdPatchId:
+		var diff jd.Diff
+		diff, err = jd.ReadDiffString(in)
+		if err == nil {
+			out, err = a.renderPatch(diff)
+		}
+	case translatePatchJdId:
+		var diff jd.Diff
+		diff, err = jd.ReadPatchString(in)
+		if err == nil {
+			out = diff.Render()
+		}
+	case translateJsonYamlId:
+		var node jd.JsonNode
+		node, err = jd.ReadJsonString(in)
+		if err == nil {
+			out = node.Yaml()
+		}
+	case translateYamlJsonId:
+		var node jd.JsonNode
+		node, err = jd.ReadYamlString(in)
+		if err == nil {
+			out = node.Json()
+		}
+	}
+	if err != nil {
+		a.setLabel(aErrorId, err.Error())
+		a.setTextarea(bJsonId, "")
+		return
+	}
+	a.setLabel(aErrorId, "")
+	a.setTextarea(bJsonId, out)
+}
+
 func (a *app) getElementById(id string) js.Value {
 	return a.doc.Call("getElementById", id)
 }
 
 func (a *app) setLabel(id, msg string) {
-	a.getElementById(id).Set("innerHTML", msg)
+	// Labels may hold input from shared links, so never parse them as
+	// HTML.
+	a.getElementById(id).Set("textContent", msg)
 }
 
 func (a *app) setTextarea(id, text string) {
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"syscall/js"
+)
+
+const (
+	copyLinkId       = "copy-link"
+	copyLinkStatusId = "copy-link-status"
+)
+
+// state is the part of the app kept in the URL fragment. Only inputs
+// are kept. Outputs are recomputed when the state is restored. The
+// fragment is never sent to a server so nothing leaves the browser.
+type state struct {
+	A          string `json:"a,omitempty"`
+	B          string `json:"b,omitempty"`
+	Diff       string `json:"d,omitempty"`
+	Mode       string `json:"m,omitempty"`
+	Format     string `json:"f,omitempty"`
+	DiffFormat string `json:"df,omitempty"`
+	Array      string `json:"ar,omitempty"`
+	View       bool   `json:"v,omitempty"`
+	Setkeys    string `json:"k,omitempty"`
+	Ignore     string `json:"i,omitempty"`
+	Pretty     bool   `json:"p,omitempty"`
+	Output     string `json:"o,omitempty"`
+	Translate  string `json:"t,omitempty"`
+}
+
+// encodeState compresses s into a string safe for a URL fragment.
+func encodeState(s state) (string, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(b); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeState reverses encodeState.
+func decodeState(fragment string) (state, error) {
+	var s state
+	b, err := base64.RawURLEncoding.DecodeString(fragment)
+	if err != nil {
+		return s, err
+	}
+	r := flate.NewReader(bytes.NewReader(b))
+	defer r.Close()
+	b, err = ioutil.ReadAll(r)
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
+
+// getState captures the inputs of the current mode and the options.
+func (a *app) getState() state {
+	s := state{
+		A:          a.getElementById(aJsonId).Get("value").String(),
+		Mode:       a.mode,
+		Format:     a.format,
+		DiffFormat: a.diffFormat,
+		Array:      a.array,
+		View:       a.showView,
+		Setkeys:    a.getElementById(setkeysId).Get("value").String(),
+		Ignore:     a.getElementById(ignoreId).Get("value").String(),
+		Pretty:     a.prettyPatch,
+		Output:     a.outputFormat,
+		Translate:  a.translation,
+	}
+	switch a.mode {
+	case modeDiffId:
+		s.B = a.getElementById(bJsonId).Get("value").String()
+	case modePatchId:
+		s.Diff = a.getElementById(diffId).Get("value").String()
+	}
+	return s
+}
+
+// setState restores inputs and options. Unknown options are ignored.
+func (a *app) setState(s state) {
+	a.setTextarea(aJsonId, s.A)
+	a.setTextarea(bJsonId, s.B)
+	a.setTextarea(diffId, s.Diff)
+	a.setTextarea(setkeysId, s.Setkeys)
+	a.setTextarea(ignoreId, s.Ignore)
+	a.showView = s.View
+	a.getElementById(viewShowId).Set("checked", s.View)
+	a.prettyPatch = s.Pretty
+	a.getElementById(patchPrettyId).Set("checked", s.Pretty)
+	for _, option := range []struct {
+		value string
+		field *string
+		ids   []string
+	}{
+		{s.Mode, &a.mode, []string{modeDiffId, modePatchId, modeTranslateId}},
+		{s.Format, &a.format, []string{formatJsonId, formatYamlId}},
+		{s.DiffFormat, &a.diffFormat, []string{diffFormatJdId, diffFormatPatchId}},
+		{s.Array, &a.array, []string{arrayListId, arraySetId, arrayMsetId}},
+		{s.Output, &a.outputFormat, []string{outputFormatSameId, outputFormatJsonId, outputFormatYamlId}},
+		{s.Translate, &a.translation, []string{translateJsonYamlId, translateYamlJsonId, translateJdPatchId, translatePatchJdId}},
+	} {
+		for _, id := range option.ids {
+			if id == option.value {
+				*option.field = id
+			}
+		}
+		for _, id := range option.ids {
+			a.getElementById(id).Set("checked", id == *option.field)
+		}
+	}
+}
+
+// restoreFragment loads the state in the URL fragment, if any.
+func (a *app) restoreFragment() {
+	fragment := strings.TrimPrefix(js.Global().Get("location").Get("hash").String(), "#")
+	if fragment == "" || fragment == a.fragment {
+		return
+	}
+	s, err := decodeState(fragment)
+	if err != nil {
+		a.setLabel(copyLinkStatusId, "Invalid link: "+err.Error())
+		return
+	}
+	a.fragment = fragment
+	a.setState(s)
+}
+
+// updateFragment writes the current state to the URL fragment without
+// adding a history entry.
+func (a *app) updateFragment() {
+	location := js.Global().Get("location")
+	url := location.Get("pathname").String() + location.Get("search").String()
+	s := a.getState()
+	fragment := ""
+	if s != (state{
+		Mode:       modeDiffId,
+		Format:     formatJsonId,
+		DiffFormat: diffFormatJdId,
+		Array:      arrayListId,
+		Output:     outputFormatSameId,
+		Translate:  translateJsonYamlId,
+	}) {
+		var err error
+		fragment, err = encodeState(s)
+		if err != nil {
+			a.setLabel(copyLinkStatusId, err.Error())
+			return
+		}
+		url += "#" + fragment
+	}
+	if fragment == a.fragment {
+		return
+	}
+	a.fragment = fragment
+	js.Global().Get("history").Call("replaceState", nil, "", url)
+}
+
+// watchFragment restores the state when the fragment is changed by
+// navigation and copies a link to the state on request.
+func (a *app) watchFragment() error {
+	hashListener := func(_ js.Value, _ []js.Value) interface{} {
+		defer a.catchPanic()
+		a.mux.Lock()
+		defer a.mux.Unlock()
+		a.restoreFragment()
+		a.changeCh <- struct{}{}
+		return nil
+	}
+	js.Global().Call("addEventListener", "hashchange", js.FuncOf(hashListener))
+	copyListener := func(_ js.Value, _ []js.Value) interface{} {
+		defer a.catchPanic()
+		a.mux.Lock()
+		defer a.mux.Unlock()
+		a.updateFragment()
+		a.copyLink(js.Global().Get("location").Get("href").String())
+		return nil
+	}
+	element := a.getElementById(copyLinkId)
+	if element.IsNull() {
+		return fmt.Errorf("id %v not found", copyLinkId)
+	}
+	element.Call("addEventListener", "click", js.FuncOf(copyListener))
+	return nil
+}
+
+func (a *app) copyLink(link string) {
+	clipboard := js.Global().Get("navigator").Get("clipboard")
+	if clipboard.IsUndefined() {
+		// The clipboard API is only available in secure contexts.
+		js.Global().Call("prompt", "Copy link:", link)
+		return
+	}
+	var copied, failed js.Func
+	copied = js.FuncOf(func(_ js.Value, _ []js.Value) interface{} {
+		a.setLabel(copyLinkStatusId, "Link copied.")
+		copied.Release()
+		failed.Release()
+		return nil
+	})
+	failed = js.FuncOf(func(_ js.Value, _ []js.Value) interface{} {
+		js.Global().Call("prompt", "Copy link:", link)
+		copied.Release()
+		failed.Release()
+		return nil
+	})
+	clipboard.Call("writeText", link).Call("then", copied, failed)
+}
@@ -0,0 +1,488 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"syscall/js"
+
+	jd "github.com/josephburnett/jd/lib"
+)
+
+const (
+	viewId        = "view"
+	viewShowId    = "view-show"
+	viewAId       = "view-a"
+	viewBId       = "view-b"
+	sideA         = 0
+	sideB         = 1
+	jumpClass     = "jump"
+	changedClassA = "changed-a"
+	changedClassB = "changed-b"
+)
+
+// slot is an element of a list being patched. It remembers its index
+// in a so list indices in diff paths, which are relative to the list
+// as patched so far, can be mapped to indices in a and b.
+type slot struct {
+	a int // -1 for elements added by the diff
+}
+
+// component is a resolved path element. Either a key, a slot, the
+// identity or value of a set element or metadata for the following components.
+type component struct {
+	key   string
+	slot  *slot
+	ident jd.JsonNode
+	value jd.JsonNode
+	meta  jd.JsonNode
+}
+
+// mark is a changed location in a or b.
+type mark struct {
+	side  int
+	comps []component
+}
+
+// changes maps the elements of a diff to locations in a and b. It uses
+// the paths of the diff rather than comparing a and b again.
+type changes struct {
+	a     jd.JsonNode
+	b     jd.JsonNode
+	lists map[string][]*slot
+	marks []mark
+	// hunks holds the indices of the first marks of each diff element.
+	hunks []int
+}
+
+func newChanges(a, b jd.JsonNode, diff jd.Diff) *changes {
+	c := &changes{
+		a:     a,
+		b:     b,
+		lists: map[string][]*slot{},
+	}
+	for _, e := range diff {
+		c.hunks = append(c.hunks, len(c.marks))
+		c.add(e)
+	}
+	return c
+}
+
+func (c *changes) add(e jd.DiffElement) {
+	var comps []component
+	for i, p := range e.Path {
+		switch jd.KindOf(p) {
+		case jd.String:
+			key, _ := jd.StringValue(p)
+			comps = append(comps, component{key: key})
+		case jd.Number:
+			f, _ := jd.NumberValue(p)
+			if i == len(e.Path)-1 {
+				c.addListHunk(comps, int(f), e)
+				return
+			}
+			slots := c.list(comps)
+			if int(f) < 0 || int(f) >= len(slots) {
+				c.mark(sideA, comps)
+				c.mark(sideB, comps)
+				return
+			}
+			comps = append(comps, component{slot: slots[int(f)]})
+		case jd.Array:
+			if format, ok := jd.Index(p, 0); ok && jd.Len(p) == 1 && isEmbeddedFormat(format) {
+				// Embedded documents are shown as strings. The
+				// string is marked instead.
+				c.mark(sideA, comps)
+				c.mark(sideB, comps)
+				return
+			}
+			comps = append(comps, component{meta: p})
+		case jd.Object:
+			if jd.Len(p) == 0 {
+				// Elements of sets without keys are found by value.
+				for _, v := range nonVoid(e.OldValues) {
+					c.mark(sideA, appendComponent(comps, component{value: v}))
+				}
+				for _, v := range nonVoid(e.NewValues) {
+					c.mark(sideB, appendComponent(comps, component{value: v}))
+				}
+				return
+			}
+			comps = append(comps, component{ident: p})
+		default:
+			c.mark(sideA, comps)
+			c.mark(sideB, comps)
+			return
+		}
+	}
+	if len(nonVoid(e.OldValues)) > 0 {
+		c.mark(sideA, comps)
+	}
+	if len(nonVoid(e.NewValues)) > 0 {
+		c.mark(sideB, comps)
+	}
+}
+
+func (c *changes) addListHunk(parent []component, i int, e jd.DiffElement) {
+	slots := c.list(parent)
+	if i == -1 {
+		// Appended to the end of the list.
+		i = len(slots)
+	}
+	if i < 0 || i > len(slots) {
+		c.mark(sideA, parent)
+		c.mark(sideB, parent)
+		return
+	}
+	end := i + len(nonVoid(e.OldValues))
+	if end > len(slots) {
+		end = len(slots)
+	}
+	added := []*slot{}
+	for range nonVoid(e.NewValues) {
+		added = append(added, &slot{a: -1})
+	}
+	for _, s := range slots[i:end] {
+		c.mark(sideA, appendComponent(parent, component{slot: s}))
+	}
+	for _, s := range added {
+		c.mark(sideB, appendComponent(parent, component{slot: s}))
+	}
+	patched := append(append([]*slot{}, slots[:i]...), added...)
+	patched = append(patched, slots[end:]...)
+	c.lists[listKey(parent)] = patched
+}
+
+// list returns the slots of the list at comps, as patched so far.
+func (c *changes) list(comps []component) []*slot {
+	key := listKey(comps)
+	if slots, ok := c.lists[key]; ok {
+		return slots
+	}
+	slots := []*slot{}
+	if p, ok := c.path(sideA, comps); ok {
+		if n, ok := jd.Get(c.a, p); ok && jd.KindOf(n) == jd.Array {
+			for i := 0; i < jd.Len(n); i++ {
+				slots = append(slots, &slot{a: i})
+			}
+		}
+	}
+	c.lists[key] = slots
+	return slots
+}
+
+func (c *changes) mark(side int, comps []component) {
+	c.marks = append(c.marks, mark{side, comps})
+}
+
+// path returns the location of comps in a or b. It must be called on
+// side b only after all diff elements are added.
+func (c *changes) path(side int, comps []component) (jd.Path, bool) {
+	p := jd.Path{}
+	meta := jd.Path{}
+	for i, comp := range comps {
+		switch {
+		case comp.meta != nil:
+			meta = append(meta, comp.meta)
+			continue
+		case comp.ident != nil || comp.value != nil:
+			index, ok := c.setIndex(side, p, meta, comp)
+			if !ok {
+				return nil, false
+			}
+			p = append(p, jd.NewNumber(float64(index)))
+			meta = jd.Path{}
+			continue
+		case comp.slot == nil:
+			p = append(p, jd.NewString(comp.key))
+			meta = jd.Path{}
+			continue
+		}
+		meta = jd.Path{}
+		if side == sideA {
+			if comp.slot.a < 0 {
+				return nil, false
+			}
+			p = append(p, jd.NewNumber(float64(comp.slot.a)))
+			continue
+		}
+		index := -1
+		for j, s := range c.lists[listKey(comps[:i])] {
+			if s == comp.slot {
+				index = j
+			}
+		}
+		if index < 0 {
+			return nil, false
+		}
+		p = append(p, jd.NewNumber(float64(index)))
+	}
+	return p, true
+}
+
+// setIndex finds the index of the set element identified by comp in the
+// set at p.
+func (c *changes) setIndex(side int, p, meta jd.Path, comp component) (int, bool) {
+	root := c.a
+	if side == sideB {
+		root = c.b
+	}
+	set, ok := jd.Get(root, p)
+	if !ok {
+		return 0, false
+	}
+	element := comp.value
+	if element == nil {
+		lookup := append(append(append(jd.Path{}, p...), meta...), comp.ident)
+		element, ok = jd.Get(root, lookup)
+		if !ok {
+			return 0, false
+		}
+	}
+	for i := 0; i < jd.Len(set); i++ {
+		if e, _ := jd.Index(set, i); e.Equals(element) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func isEmbeddedFormat(n jd.JsonNode) bool {
+	format, _ := jd.StringValue(n)
+	return format == "json" || format == "yaml"
+}
+
+// listKey identifies a list by its location in a.
+func listKey(comps []component) string {
+	b := strings.Builder{}
+	for _, comp := range comps {
+		switch {
+		case comp.meta != nil:
+		case comp.ident != nil:
+			fmt.Fprintf(&b, "/%v", comp.ident.Json())
+		case comp.value != nil:
+			fmt.Fprintf(&b, "/=%v", comp.value.Json())
+		case comp.slot == nil:
+			fmt.Fprintf(&b, "/%q", comp.key)
+		default:
+			fmt.Fprintf(&b, "/%v", comp.slot.a)
+		}
+	}
+	return b.String()
+}
+
+func appendComponent(comps []component, comp component) []component {
+	return append(append([]component{}, comps...), comp)
+}
+
+func appendPath(p jd.Path, n jd.JsonNode) jd.Path {
+	return append(append(jd.Path{}, p...), n)
+}
+
+func nonVoid(nodes []jd.JsonNode) []jd.JsonNode {
+	values := []jd.JsonNode{}
+	for _, n := range nodes {
+		if jd.KindOf(n) != jd.Void {
+			values = append(values, n)
+		}
+	}
+	return values
+}
+
+// sideView renders one document with its changes highlighted.
+type sideView struct {
+	side     int
+	changed  map[string]bool
+	contains map[string]bool
+	// anchors maps paths to element ids for jumping.
+	anchors map[string]string
+	b       strings.Builder
+}
+
+func newSideView(side int, c *changes) *sideView {
+	v := &sideView{
+		side:     side,
+		changed:  map[string]bool{},
+		contains: map[string]bool{},
+		anchors:  map[string]string{},
+	}
+	for _, m := range c.marks {
+		if m.side != side {
+			continue
+		}
+		p, ok := c.path(side, m.comps)
+		if !ok {
+			continue
+		}
+		v.changed[p.String()] = true
+		for i := range p {
+			v.contains[p[:i].String()] = true
+		}
+	}
+	return v
+}
+
+func (v *sideView) render(n jd.JsonNode, p jd.Path, label string) {
+	ps := p.String()
+	id := fmt.Sprintf("view-%v-%v", v.side, len(v.anchors))
+	v.anchors[ps] = id
+	class := ""
+	if v.changed[ps] {
+		class = changedClassA
+		if v.side == sideB {
+			class = changedClassB
+		}
+	}
+	var open, close string
+	switch jd.KindOf(n) {
+	case jd.Object:
+		open, close = "{", "}"
+	case jd.Array:
+		open, close = "[", "]"
+	}
+	if open == "" || jd.Len(n) == 0 {
+		fmt.Fprintf(&v.b, `<div id="%v" class="%v">%v%v</div>`,
+			id, class, label, html.EscapeString(n.Json()))
+		return
+	}
+	detailsOpen := ""
+	if v.changed[ps] || v.contains[ps] {
+		detailsOpen = " open"
+	}
+	fmt.Fprintf(&v.b, `<details id="%v"%v><summary class="%v">%v%v<span class="collapsed"> %v items %v</span></summary><div class="children">`,
+		id, detailsOpen, class, label, open, jd.Len(n), close)
+	if jd.KindOf(n) == jd.Object {
+		for _, k := range jd.Keys(n) {
+			child, _ := jd.Field(n, k)
+			keyJson, _ := json.Marshal(k)
+			v.render(child, appendPath(p, jd.NewString(k)), html.EscapeString(string(keyJson))+": ")
+		}
+	} else {
+		for i := 0; i < jd.Len(n); i++ {
+			child, _ := jd.Index(n, i)
+			v.render(child, appendPath(p, jd.NewNumber(float64(i))), "")
+		}
+	}
+	fmt.Fprintf(&v.b, `</div><div class="%v">%v</div></details>`, class, close)
+}
+
+// anchor returns the element id of p or its nearest rendered parent.
+func (v *sideView) anchor(p jd.Path) string {
+	for {
+		if id, ok := v.anchors[p.String()]; ok {
+			return id
+		}
+		if len(p) == 0 {
+			return ""
+		}
+		p = p[:len(p)-1]
+	}
+}
+
+// renderedView is the side by side view currently shown.
+type renderedView struct {
+	changes *changes
+	sides   [2]*sideView
+}
+
+// printView renders a and b side by side with the changes of diff
+// highlighted. Either node is nil when it couldn't be read.
+func (a *app) printView(aNode, bNode jd.JsonNode, diff jd.Diff) {
+	if !a.showView {
+		a.getElementById(viewId).Set("hidden", true)
+		a.view = nil
+		return
+	}
+	a.getElementById(viewId).Set("hidden", false)
+	if aNode == nil || bNode == nil {
+		a.getElementById(viewAId).Set("innerHTML", "")
+		a.getElementById(viewBId).Set("innerHTML", "")
+		a.view = nil
+		return
+	}
+	c := newChanges(aNode, bNode, diff)
+	views := [2]*sideView{newSideView(sideA, c), newSideView(sideB, c)}
+	views[sideA].render(aNode, jd.Path{}, "")
+	views[sideB].render(bNode, jd.Path{}, "")
+	a.getElementById(viewAId).Set("innerHTML", views[sideA].b.String())
+	a.getElementById(viewBId).Set("innerHTML", views[sideB].b.String())
+	a.view = &renderedView{changes: c, sides: views}
+}
+
+// watchDiffClick jumps to the location of the diff hunk clicked on.
+func (a *app) watchDiffClick() error {
+	listener := func(this js.Value, _ []js.Value) interface{} {
+		defer a.catchPanic()
+		a.mux.Lock()
+		defer a.mux.Unlock()
+		text := this.Get("value").String()
+		caret := this.Get("selectionStart").Int()
+		if caret > len(text) {
+			caret = len(text)
+		}
+		hunk := -1
+		for _, line := range strings.SplitAfter(text[:caret], "\n") {
+			if strings.HasPrefix(line, "@ ") {
+				hunk++
+			}
+		}
+		a.jumpToHunk(hunk)
+		return nil
+	}
+	element := a.getElementById(diffId)
+	if element.IsNull() {
+		return fmt.Errorf("id %v not found", diffId)
+	}
+	element.Call("addEventListener", "click", js.FuncOf(listener))
+	return nil
+}
+
+func (a *app) jumpToHunk(hunk int) {
+	a.clearJump()
+	if a.view == nil || hunk < 0 || hunk >= len(a.view.changes.hunks) {
+		return
+	}
+	c := a.view.changes
+	end := len(c.marks)
+	if hunk+1 < len(c.hunks) {
+		end = c.hunks[hunk+1]
+	}
+	for side, v := range a.view.sides {
+		for _, m := range c.marks[c.hunks[hunk]:end] {
+			if m.side != side {
+				continue
+			}
+			p, ok := c.path(side, m.comps)
+			if !ok {
+				continue
+			}
+			a.jumpTo(v.anchor(p))
+			break
+		}
+	}
+}
+
+func (a *app) jumpTo(id string) {
+	if id == "" {
+		return
+	}
+	e := a.getElementById(id)
+	e.Get("classList").Call("add", jumpClass)
+	// Expand collapsed parents.
+	for p := e; !p.IsNull(); p = p.Get("parentElement") {
+		if p.Get("tagName").String() == "DETAILS" {
+			p.Set("open", true)
+		}
+	}
+	e.Call("scrollIntoView", map[string]interface{}{
+		"block": "nearest",
+	})
+}
+
+func (a *app) clearJump() {
+	jumped := a.doc.Call("querySelectorAll", "."+jumpClass)
+	for i := 0; i < jumped.Length(); i++ {
+		jumped.Index(i).Get("classList").Call("remove", jumpClass)
+	}
+}